@@ -1,14 +1,16 @@
 package addon
 
 import (
-	"encoding/json"
+	"context"
+	"errors"
 	"fmt"
 	"io"
-	"net/http"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"slices"
 	"strings"
+	"sync"
 	"time"
 
 	"gmod-addon-manager/config"
@@ -23,121 +25,287 @@ type Addon struct {
 	Tags        []string
 	Installed   bool
 	Enabled     bool
+
+	// Install details recorded in the manifest
+	Source      string
+	InstalledAt time.Time
+	FileCount   int
+	Size        int64
+
+	// UpdateAvailable is set when the workshop copy is newer than the
+	// installed one, based on the last known workshop info
+	UpdateAvailable bool
+
+	// Stale is set when the workshop info is from an expired cache entry
+	// because the workshop couldn't be reached
+	Stale bool
 }
 
 type Manager struct {
-	config  *config.Config
-	cache   *PersistentCache
-	verbose bool
+	config   *config.Config
+	cache    *PersistentCache
+	manifest *Manifest
+	verbose  bool
+
+	downloaders     []Downloader
+	progressHandler func(ProgressEvent)
+
+	jobs       *JobQueue
+	jobsOnce   sync.Once
+	addonLocks sync.Map // addon ID -> *sync.Mutex, see lockAddon
+
+	strict bool
+	noDeps bool
+
+	dirLock     *dirLock
+	lockTimeout time.Duration
 }
 
 func NewManager(cfg *config.Config) (*Manager, error) {
-	// Initialize persistent cache with 24-hour TTL
-	cache, err := NewPersistentCache(24 * time.Hour)
+	cache, err := NewPersistentCache(cfg.CacheTTLDuration())
 	if err != nil {
 		return nil, fmt.Errorf("failed to initialize cache: %w", err)
 	}
 
-	return &Manager{
-		config:  cfg,
-		cache:   cache,
-		verbose: true, // Default to verbose for CLI mode
-	}, nil
+	manifestPath := filepath.Join(cfg.AddonDir, "0", manifestFileName)
+	manifest, manifestErr := LoadManifest(manifestPath)
+	corrupt := errors.Is(manifestErr, errCorruptManifest)
+	if manifestErr != nil && !corrupt {
+		return nil, fmt.Errorf("failed to load manifest: %w", manifestErr)
+	}
+
+	m := &Manager{
+		config:   cfg,
+		cache:    cache,
+		manifest: manifest,
+		verbose:  true, // Default to verbose for CLI mode
+
+		dirLock:     &dirLock{path: filepath.Join(cfg.AddonDir, "0", lockFileName)},
+		lockTimeout: DefaultLockTimeout,
+	}
+
+	if m.downloaders, err = m.newDownloaders(cfg); err != nil {
+		return nil, fmt.Errorf("failed to configure downloaders: %w", err)
+	}
+
+	// Build the manifest from disk the first time it is used, or when the
+	// recorded one is damaged
+	if corrupt {
+		m.log(fmt.Sprintf("Warning: %v; rebuilding it from the installed addons.", manifestErr))
+	}
+	if _, err := os.Stat(manifestPath); corrupt || (os.IsNotExist(err) && dirExists(cfg.OutDir)) {
+		if _, err := m.Reconcile(); err != nil {
+			return nil, fmt.Errorf("failed to build manifest: %w", err)
+		}
+	}
+
+	return m, nil
+}
+
+// isAddonDir reports whether an entry of OutDir is an installed addon
+// rather than a backup kept during an install
+func isAddonDir(entry os.DirEntry) bool {
+	return entry.IsDir() && !strings.HasPrefix(entry.Name(), ".")
+}
+
+func dirExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
 }
 
 func (m *Manager) SetVerbose(verbose bool) {
 	m.verbose = verbose
 }
 
+// SetStrict makes installs fail instead of warning when an addon contains
+// paths outside GMod's whitelist
+func (m *Manager) SetStrict(strict bool) {
+	m.strict = strict
+}
+
+// SetInstallDependencies controls whether GetAddon also installs the
+// workshop items an addon requires
+func (m *Manager) SetInstallDependencies(enabled bool) {
+	m.noDeps = !enabled
+}
+
 func (m *Manager) log(message string) {
 	if m.verbose {
 		fmt.Println(message)
 	}
 }
 
-func (m *Manager) GetAddon(id string) error {
-	// Run steamcmd to get the addon with output
-	steamCmd := exec.Command(
-		m.config.SteamCmdPath,
-		"+login", "anonymous",
-		"+workshop_download_item", "4000", id,
-		"+quit",
-	)
+// InstallResult is the outcome of installing one addon from a batch
+type InstallResult struct {
+	ID         string
+	Dependency bool // installed because another addon requires it
+	Err        error
+}
 
-	// Set up output pipes to capture and display SteamCMD output
-	steamCmd.Stdout = os.Stdout
-	steamCmd.Stderr = os.Stderr
+// GetAddon installs an addon from the workshop, installing any missing
+// required items first
+func (m *Manager) GetAddon(id string) error {
+	return m.getAddon(context.Background(), id)
+}
 
-	m.log(fmt.Sprintf("Downloading addon %s...", id))
-	if err := steamCmd.Run(); err != nil {
-		return fmt.Errorf("failed to run steamcmd: %w", err)
+func (m *Manager) getAddon(ctx context.Context, id string) error {
+	for _, result := range m.getAddons(ctx, []string{id}) {
+		if result.Err == nil {
+			continue
+		}
+		if result.Dependency {
+			return fmt.Errorf("failed to install dependency %s: %w", result.ID, result.Err)
+		}
+		return result.Err
 	}
-	m.log("Download completed.")
+	return nil
+}
 
-	// Find the downloaded file
-	downloadDir := filepath.Join(m.config.DownloadDir, id)
+// GetAddons installs many addons (and their missing dependencies) using a
+// single download session where the downloader supports it. Every addon is
+// processed even if others fail, with one result per addon in install order.
+func (m *Manager) GetAddons(ids []string) []InstallResult {
+	return m.getAddons(context.Background(), ids)
+}
 
-	// Get the first file (should be either .gma or _legacy.bin)
-	downloadedFileName, err := file.First(downloadDir)
+func (m *Manager) getAddons(ctx context.Context, ids []string) []InstallResult {
+	unlock, err := m.lock()
 	if err != nil {
-		return fmt.Errorf("failed to get the first file: %w", err)
+		results := make([]InstallResult, 0, len(ids))
+		for _, id := range ids {
+			results = append(results, InstallResult{ID: id, Err: err})
+		}
+		return results
 	}
-	downloadedFilePath := filepath.Join(downloadDir, downloadedFileName)
+	defer unlock()
 
-	// Create output directory
-	outDir := filepath.Join(m.config.OutDir, id)
-	if err := os.MkdirAll(outDir, 0755); err != nil {
-		return fmt.Errorf("failed to create output directory: %w", err)
+	plan, requested := m.installPlan(ids)
+	paths, downloadErrs := m.downloadAll(ctx, plan)
+
+	// Extract the downloaded items on the configured number of workers
+	results := make([]InstallResult, len(plan))
+	sem := make(chan struct{}, max(m.config.Workers, 1))
+	var wg sync.WaitGroup
+	for i, id := range plan {
+		results[i] = InstallResult{ID: id, Dependency: !requested[id]}
+		if err, failed := downloadErrs[id]; failed {
+			results[i].Err = err
+			continue
+		}
+
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			if err := ctx.Err(); err != nil {
+				results[i].Err = err
+				return
+			}
+			results[i].Err = m.installDownloaded(id, paths[id], SourceWorkshop)
+		}()
 	}
+	wg.Wait()
+
+	return results
+}
 
-	// Create tmp directory
-	tmpDir := filepath.Join(m.config.TmpDir, id)
-	if err := os.MkdirAll(tmpDir, 0755); err != nil {
-		return fmt.Errorf("failed to create tmp directory: %w", err)
+// installAddon downloads, extracts and enables a single addon
+func (m *Manager) installAddon(ctx context.Context, id string) error {
+	downloadedFilePath, err := m.download(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return err
 	}
 
-	gmaPath := filepath.Join(tmpDir, id+".gma")
+	return m.installDownloaded(id, downloadedFilePath, SourceWorkshop)
+}
+
+// lockAddon serializes work on one addon within this process, since
+// installs and verifies share TmpDir/<id> and OutDir/<id>
+func (m *Manager) lockAddon(id string) func() {
+	mu, _ := m.addonLocks.LoadOrStore(id, &sync.Mutex{})
+	mu.(*sync.Mutex).Lock()
+	return mu.(*sync.Mutex).Unlock
+}
 
+// prepareGMA turns a downloaded workshop file into a plain .gma at gmaPath
+func prepareGMA(downloadedFilePath, gmaPath string, progress file.ProgressFunc) error {
 	// Handle .bin file (extract and rename to .gma)
-	if strings.HasSuffix(downloadedFileName, "_legacy.bin") {
-		if err := file.ExtractLZMA(downloadedFilePath, gmaPath); err != nil {
+	if hasSuffixFold(downloadedFilePath, "_legacy.bin") {
+		if err := file.ExtractLZMAProgress(downloadedFilePath, gmaPath, progress); err != nil {
 			return fmt.Errorf("failed to extract bin file: %w", err)
 		}
-	} else if strings.HasSuffix(downloadedFileName, ".gma") {
-		// gmaPath = downloadedFilePath
+	} else if hasSuffixFold(downloadedFilePath, ".gma") {
 		if err := file.Copy(downloadedFilePath, gmaPath); err != nil {
 			return fmt.Errorf("failed to copy gma file: %w", err)
 		}
 	} else {
-		return fmt.Errorf("unknown file type: %s", downloadedFileName)
+		return fmt.Errorf("unknown file type: %s", filepath.Base(downloadedFilePath))
 	}
+	return nil
+}
 
-	// Execute GMAD tool to extract directly to output directory
-	gmadCmd := exec.Command(
-		m.config.GMADPath,
-		"extract",
-		"-file", gmaPath,
-		"-out", outDir,
-	)
-
-	m.log(fmt.Sprintf("Extracting addon %s...", id))
-	if err := gmadCmd.Run(); err != nil {
-		return fmt.Errorf("failed to run gmad: %w", err)
+// extractGMA extracts a GMA archive using gmad when it is configured and
+// present, falling back to the native reader otherwise
+func (m *Manager) extractGMA(gmaPath, outDir string, progress file.ProgressFunc) error {
+	if m.config.GMADPath != "" {
+		if _, err := os.Stat(m.config.GMADPath); err == nil {
+			// Execute GMAD tool to extract directly to output directory
+			gmadCmd := exec.Command(
+				m.config.GMADPath,
+				"extract",
+				"-file", gmaPath,
+				"-out", outDir,
+			)
+			if err := gmadCmd.Run(); err != nil {
+				return fmt.Errorf("failed to run gmad: %w", err)
+			}
+			return nil
+		}
 	}
-	m.log("Extraction completed.")
 
-	m.EnableAddon(id)
+	if err := file.ExtractGMAProgress(gmaPath, outDir, progress); err != nil {
+		return fmt.Errorf("failed to extract gma file: %w", err)
+	}
+	return nil
+}
 
-	// Clean up tmp directory
-	if err := os.RemoveAll(tmpDir); err != nil {
-		return fmt.Errorf("failed to clean up tmp directory: %w", err)
+// checkWhitelist warns about archive paths GMod won't load, or fails in
+// strict mode
+func (m *Manager) checkWhitelist(id string, gma *file.GMA) error {
+	violations := file.CheckGMAWhitelist(gma)
+	if len(violations) == 0 {
+		return nil
 	}
 
-	m.log(fmt.Sprintf("Addon %s installed and enabled successfully.", id))
+	if m.strict {
+		return fmt.Errorf("addon %s contains %d path(s) outside the whitelist, first: %s", id, len(violations), violations[0])
+	}
+	for _, v := range violations {
+		m.log(fmt.Sprintf("Warning: %s", v))
+	}
 	return nil
 }
 
+// LintAddon checks the files of an installed addon against GMod's whitelist
+func (m *Manager) LintAddon(id string) ([]file.WhitelistViolation, error) {
+	addonDir := filepath.Join(m.config.OutDir, id)
+	if _, err := os.Stat(addonDir); os.IsNotExist(err) {
+		return nil, fmt.Errorf("addon %s is not installed", id)
+	}
+	return file.CheckDirWhitelist(addonDir)
+}
+
 func (m *Manager) EnableAddon(id string) error {
+	unlock, err := m.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
 	// Check if addon is installed
 	addonDir := filepath.Join(m.config.OutDir, id)
 	if _, err := os.Stat(addonDir); os.IsNotExist(err) {
@@ -155,11 +323,21 @@ func (m *Manager) EnableAddon(id string) error {
 		return fmt.Errorf("failed to create symlink: %w", err)
 	}
 
+	if err := m.recordEnabled(id, true); err != nil {
+		return err
+	}
+
 	m.log(fmt.Sprintf("Addon %s enabled successfully.", id))
 	return nil
 }
 
 func (m *Manager) DisableAddon(id string) error {
+	unlock, err := m.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
 	// Check if addon is installed
 	addonDir := filepath.Join(m.config.OutDir, id)
 	if _, err := os.Stat(addonDir); os.IsNotExist(err) {
@@ -177,11 +355,21 @@ func (m *Manager) DisableAddon(id string) error {
 		return fmt.Errorf("failed to remove symlink: %w", err)
 	}
 
+	if err := m.recordEnabled(id, false); err != nil {
+		return err
+	}
+
 	m.log(fmt.Sprintf("Addon %s disabled successfully.", id))
 	return nil
 }
 
 func (m *Manager) RemoveAddon(id string) error {
+	unlock, err := m.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
 	// Check if addon is installed
 	addonDir := filepath.Join(m.config.OutDir, id)
 	if _, err := os.Stat(addonDir); os.IsNotExist(err) {
@@ -201,8 +389,16 @@ func (m *Manager) RemoveAddon(id string) error {
 		return fmt.Errorf("failed to remove addon directory: %w", err)
 	}
 
+	if err := os.RemoveAll(m.keptArchiveDir(id)); err != nil {
+		return fmt.Errorf("failed to remove kept archive: %w", err)
+	}
+
+	if err := m.manifest.Delete(id); err != nil {
+		return err
+	}
+
 	// Clear the cache for this addon
-	if err := m.RefreshCache(id); err != nil {
+	if err := m.cache.Delete(id); err != nil {
 		return fmt.Errorf("failed to clear cache: %w", err)
 	}
 
@@ -210,16 +406,68 @@ func (m *Manager) RemoveAddon(id string) error {
 	return nil
 }
 
-func (m *Manager) RefreshCache(id string) error {
-	// Clear the cache for this addon
-	cacheFile := m.cache.cacheFilePath(id)
-	if err := os.Remove(cacheFile); err != nil && !os.IsNotExist(err) {
-		return fmt.Errorf("failed to clear cache: %w", err)
+// PackAddon repacks an installed addon into a GMA archive at dst.
+// Metadata comes from the addon's addon.json, or from the workshop when
+// the extracted folder has none.
+func (m *Manager) PackAddon(id, dst string) error {
+	addonDir := filepath.Join(m.config.OutDir, id)
+	if _, err := os.Stat(addonDir); os.IsNotExist(err) {
+		return fmt.Errorf("addon %s is not installed", id)
+	}
+
+	meta, err := file.ReadAddonJSON(addonDir)
+	if errors.Is(err, os.ErrNotExist) {
+		meta = &file.GMAMetadata{Title: id}
+		if workshopAddon, err := m.getWorkshopAddonInfo(id); err == nil && workshopAddon != nil {
+			meta.Title = workshopAddon.Title
+			meta.Description = workshopAddon.Description
+			meta.Type, meta.Tags = splitWorkshopTags(workshopAddon.GetTagsAsStrings())
+		}
+	} else if err != nil {
+		return err
+	}
+
+	m.log(fmt.Sprintf("Packing addon %s...", id))
+	if err := file.PackGMA(addonDir, dst, meta); err != nil {
+		return fmt.Errorf("failed to pack addon: %w", err)
+	}
+	m.log(fmt.Sprintf("Addon %s packed to %s.", id, dst))
+	return nil
+}
+
+// addonTypes are the addon.json types gmad accepts
+var addonTypes = []string{
+	"gamemode", "map", "weapon", "vehicle", "npc", "entity",
+	"tool", "effects", "model", "servercontent",
+}
+
+// splitWorkshopTags separates the addon type from the other workshop tags
+func splitWorkshopTags(workshopTags []string) (string, []string) {
+	addonType := ""
+	tags := []string{}
+	for _, tag := range workshopTags {
+		tag = strings.ToLower(tag)
+		if addonType == "" && slices.Contains(addonTypes, tag) {
+			addonType = tag
+			continue
+		}
+		if tag != "addon" {
+			tags = append(tags, tag)
+		}
 	}
+	return addonType, tags
+}
 
-	// Refresh the addon info by fetching it again
-	_, err := m.GetAddonInfo(id)
+func (m *Manager) RefreshCache(id string) error {
+	unlock, err := m.lock()
 	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	// Fetch the addon info again. The cached entry is only replaced on
+	// success, so it stays available when the workshop can't be reached.
+	if _, err := m.RefreshWorkshopAddonsInfo([]string{id}); err != nil {
 		return fmt.Errorf("failed to refresh addon info: %w", err)
 	}
 
@@ -236,39 +484,50 @@ func (m *Manager) GetAddonsInfo() ([]Addon, error) {
 		return nil, fmt.Errorf("failed to read out directory: %w", err)
 	}
 
+	var ids []string
 	for _, entry := range entries {
-		if !entry.IsDir() {
-			continue
+		if isAddonDir(entry) {
+			ids = append(ids, entry.Name())
 		}
+	}
 
-		addonID := entry.Name()
-		// Get addon info using the existing GetAddonInfo method
-		addonInfo, err := m.GetAddonInfo(addonID)
-		if err != nil {
-			// Create addon with empty/default fields when we can't get info
-			addon := Addon{
-				ID:          addonID,
-				Installed:   true,
-				Enabled:     false,
-				Title:       "",
-				Author:      "",
-				Description: "",
-				Tags:        []string{},
-			}
-			addons = append(addons, addon)
-			continue
-		}
+	// Fetch workshop info for every addon at once; addons that couldn't be
+	// looked up are still listed with their local state only
+	workshopAddons, _ := m.GetWorkshopAddonsInfo(ids)
+
+	for _, id := range ids {
+		addon := m.localAddonInfo(id)
 
 		// Only include installed addons in the list
-		if addonInfo.Installed {
-			addons = append(addons, *addonInfo)
+		if !addon.Installed {
+			continue
 		}
+		addon.mergeWorkshopInfo(workshopAddons[id])
+		addon.UpdateAvailable = m.isOutdated(id, workshopAddons[id])
+		addons = append(addons, *addon)
 	}
 
 	return addons, nil
 }
 
 func (m *Manager) GetAddonInfo(id string) (*Addon, error) {
+	addon := m.localAddonInfo(id)
+
+	// Try to get more info from Steam Workshop
+	workshopAddon, err := m.getWorkshopAddonInfo(id)
+	if err != nil {
+		return addon, nil
+	}
+	addon.mergeWorkshopInfo(workshopAddon)
+	if addon.Installed {
+		addon.UpdateAvailable = m.isOutdated(id, workshopAddon)
+	}
+
+	return addon, nil
+}
+
+// localAddonInfo builds an Addon from the installed and enabled state on disk
+func (m *Manager) localAddonInfo(id string) *Addon {
 	// Check if addon is installed
 	addonDir := filepath.Join(m.config.OutDir, id)
 	isInstalled := true
@@ -289,102 +548,50 @@ func (m *Manager) GetAddonInfo(id string) (*Addon, error) {
 		ID:        id,
 		Installed: isInstalled,
 		Enabled:   isEnabled,
+		Tags:      []string{},
 	}
-
-	// Try to get more info from Steam Workshop
-	workshopAddon, err := m.getWorkshopAddonInfo(id)
-	if err != nil {
-		return addon, nil
-	}
-
-	// Merge the workshop info with our addon
-	if workshopAddon != nil {
-		addon.Title = workshopAddon.Title
-		addon.Author = workshopAddon.Creator
-		addon.Description = workshopAddon.Description
-		addon.Tags = workshopAddon.GetTagsAsStrings()
+	if entry, ok := m.manifest.Get(id); ok && isInstalled {
+		addon.Title = entry.Title
+		addon.Source = entry.Source
+		addon.InstalledAt = entry.InstalledAt
+		addon.FileCount = entry.FileCount
+		addon.Size = entry.Size
 	}
-
-	return addon, nil
+	return addon
 }
 
-// Helper function to get addon info from Steam Workshop with caching
-func (m *Manager) getWorkshopAddonInfo(id string) (*WorkshopAddon, error) {
-	// Check cache first
-	if cachedAddon, found, err := m.cache.Get(id); err != nil {
-		return nil, fmt.Errorf("cache error: %w", err)
-	} else if found {
-		return cachedAddon, nil
-	}
-
-	apiURL := "https://api.steampowered.com/ISteamRemoteStorage/GetPublishedFileDetails/v1/"
-
-	var requestBody string
-	if m.config.SteamAPIKey != "" {
-		requestBody = fmt.Sprintf("itemcount=1&publishedfileids[0]=%s&key=%s", id, m.config.SteamAPIKey)
-	} else {
-		requestBody = fmt.Sprintf("itemcount=1&publishedfileids[0]=%s", id)
+// mergeWorkshopInfo copies the workshop details into the addon
+func (a *Addon) mergeWorkshopInfo(workshopAddon *WorkshopAddon) {
+	if workshopAddon == nil {
+		return
 	}
-
-	resp, err := http.Post(apiURL, "application/x-www-form-urlencoded", strings.NewReader(requestBody))
-	if err != nil {
-		return nil, fmt.Errorf("failed to make API request: %w", err)
+	if workshopAddon.Title != "" {
+		a.Title = workshopAddon.Title
 	}
-	defer resp.Body.Close()
+	a.Author = workshopAddon.Creator
+	a.Description = workshopAddon.Description
+	a.Tags = workshopAddon.GetTagsAsStrings()
+	a.Stale = workshopAddon.Stale
+}
 
-	body, err := io.ReadAll(resp.Body)
+// writeFileAtomic writes r to a temporary file in the same directory and
+// renames it to path, removing the temporary file on failure
+func writeFileAtomic(path string, r io.Reader) error {
+	tmpFile, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.part")
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %w", err)
+		return fmt.Errorf("failed to create file: %w", err)
 	}
+	defer os.Remove(tmpFile.Name())
 
-	var result WorkshopResponse
-	if err := json.Unmarshal(body, &result); err != nil {
-		return nil, fmt.Errorf("failed to parse response: %w", err)
+	if _, err := io.Copy(tmpFile, r); err != nil {
+		tmpFile.Close()
+		return fmt.Errorf("failed to write file: %w", err)
 	}
-
-	if len(result.Response.PublishedFileDetails) == 0 {
-		return nil, nil
+	if err := tmpFile.Close(); err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
 	}
-
-	workshopAddon := &result.Response.PublishedFileDetails[0]
-
-	// Cache the result
-	if err := m.cache.Set(id, workshopAddon); err != nil {
-		return nil, fmt.Errorf("failed to cache workshop addon: %w", err)
+	if err := os.Rename(tmpFile.Name(), path); err != nil {
+		return fmt.Errorf("failed to move file into place: %w", err)
 	}
-
-	return workshopAddon, nil
-}
-
-// Steam Workshop API response structures
-type WorkshopResponse struct {
-	Response struct {
-		PublishedFileDetails []WorkshopAddon `json:"publishedfiledetails"`
-	} `json:"response"`
-}
-
-type WorkshopAddon struct {
-	PublishedFileID string `json:"publishedfileid"`
-	Title           string `json:"title"`
-	Creator         string `json:"creator"`
-	TimeCreated     int64  `json:"time_created"`
-	TimeUpdated     int64  `json:"time_updated"`
-	Views           int    `json:"views"`
-	Subscriptions   int    `json:"subscriptions"`
-	Favorited       int    `json:"favorited"`
-	Tags            []Tag  `json:"tags"`
-	Description     string `json:"description"`
-}
-
-type Tag struct {
-	Tag string `json:"tag"`
-}
-
-// Method to convert []Tag to []string
-func (w *WorkshopAddon) GetTagsAsStrings() []string {
-	tags := make([]string, len(w.Tags))
-	for i, tag := range w.Tags {
-		tags[i] = tag.Tag
-	}
-	return tags
+	return nil
 }
@@ -0,0 +1,60 @@
+package addon
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gmod-addon-manager/config"
+	"gmod-addon-manager/file"
+)
+
+// newTestManager creates a manager for a Garry's Mod install in a temp
+// directory, with its cache kept there too
+func newTestManager(t *testing.T) *Manager {
+	t.Helper()
+	root := t.TempDir()
+	t.Setenv("HOME", root)
+	t.Setenv("XDG_CACHE_HOME", filepath.Join(root, "cache"))
+	t.Setenv("LocalAppData", filepath.Join(root, "cache"))
+
+	addonDir := filepath.Join(root, "garrysmod", "addons")
+	cfg := &config.Config{
+		GModDir:     root,
+		AddonDir:    addonDir,
+		OutDir:      filepath.Join(addonDir, "0", "out"),
+		TmpDir:      filepath.Join(root, "tmp"),
+		DownloadDir: filepath.Join(root, "downloads"),
+		Workers:     1,
+	}
+	if err := os.MkdirAll(addonDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := NewManager(cfg)
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+	m.SetVerbose(false)
+	return m
+}
+
+// writeTestGMA packs an addon with a single lua file whose contents are
+// body, returning the archive's path
+func writeTestGMA(t *testing.T, body string) string {
+	t.Helper()
+	src := t.TempDir()
+	luaPath := filepath.Join(src, "lua", "autorun", "test.lua")
+	if err := os.MkdirAll(filepath.Dir(luaPath), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(luaPath, []byte(body), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	dst := filepath.Join(t.TempDir(), "test.gma")
+	if err := file.PackGMA(src, dst, &file.GMAMetadata{Title: "Test", Type: "tool"}); err != nil {
+		t.Fatal(err)
+	}
+	return dst
+}
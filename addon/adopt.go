@@ -0,0 +1,334 @@
+package addon
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gmod-addon-manager/file"
+)
+
+// SourceAdopted marks addons that were installed by hand and taken over by Adopt
+const SourceAdopted = "adopted"
+
+// Kinds of unmanaged addons found by PlanAdopt
+const (
+	AdoptFolder = "folder"
+	AdoptGMA    = "gma"
+)
+
+// Where PlanAdopt found an addon's workshop ID
+const (
+	IDFromName      = "name"       // <id>, <id>-<slug> or <slug>_<id>
+	IDFromAddonJSON = "addon.json" // a workshopid key
+	IDFromGMA       = "gma"        // a workshopid key in the gma's description JSON
+	IDGenerated     = "generated"  // no workshop ID, a local ID was derived
+)
+
+// AdoptCandidate is an unmanaged addon in AddonDir and what Adopt will do with it
+type AdoptCandidate struct {
+	Path     string
+	Kind     string // AdoptFolder or AdoptGMA
+	ID       string
+	IDSource string
+	Title    string
+	Skip     string // reason the addon will be left alone, empty if it will be adopted
+
+	// localID replaces ID if the workshop doesn't know it
+	localID string
+}
+
+// Workshop IDs have at least 8 digits, shorter numbers in a name are more
+// likely a year or a resolution
+var (
+	// <id> and <id>-<slug> as written by res/addon.sh
+	leadingIDRe = regexp.MustCompile(`^(\d{8,})(?:-.*)?$`)
+	// <slug>_<id> as used by some workshop downloaders
+	trailingIDRe = regexp.MustCompile(`^.*[_-](\d{8,})$`)
+)
+
+// idFromName extracts a workshop ID from a folder or file name
+func idFromName(name string) (string, bool) {
+	for _, re := range []*regexp.Regexp{leadingIDRe, trailingIDRe} {
+		if match := re.FindStringSubmatch(name); match != nil && isWorkshopID(match[1]) {
+			return match[1], true
+		}
+	}
+	return "", false
+}
+
+// PlanAdopt scans AddonDir for folders and .gma files the manager doesn't
+// know about. Workshop IDs found in names or metadata are looked up on the
+// workshop first, and a local ID is used for those it doesn't know.
+// Nothing is changed; pass the result to Adopt.
+func (m *Manager) PlanAdopt() ([]AdoptCandidate, error) {
+	dirEntries, err := os.ReadDir(m.config.AddonDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read addons directory: %w", err)
+	}
+
+	var candidates []AdoptCandidate
+	for _, dirEntry := range dirEntries {
+		name := dirEntry.Name()
+		path := filepath.Join(m.config.AddonDir, name)
+
+		// Enabled addons are symlinks into OutDir, and 0 is our own data directory
+		if name == "0" || strings.HasPrefix(name, ".") || dirEntry.Type()&os.ModeSymlink != 0 {
+			continue
+		}
+
+		switch {
+		case dirEntry.IsDir():
+			candidates = append(candidates, m.planAdoptFolder(path))
+		case strings.EqualFold(filepath.Ext(name), ".gma"):
+			candidates = append(candidates, m.planAdoptGMA(path))
+		}
+	}
+
+	m.confirmWorkshopIDs(candidates)
+
+	planned := make(map[string]bool)
+	for i := range candidates {
+		candidate := &candidates[i]
+		switch {
+		case candidate.Skip != "":
+		case dirExists(filepath.Join(m.config.OutDir, candidate.ID)):
+			candidate.Skip = fmt.Sprintf("addon %s is already installed", candidate.ID)
+		case planned[candidate.ID]:
+			candidate.Skip = fmt.Sprintf("another addon is adopted as %s", candidate.ID)
+		}
+		if candidate.Skip == "" {
+			planned[candidate.ID] = true
+		}
+	}
+
+	return candidates, nil
+}
+
+// confirmWorkshopIDs looks up the IDs that weren't generated on the
+// workshop. Candidates whose ID isn't a Garry's Mod item fall back to their
+// local ID, and those that couldn't be looked up are skipped.
+func (m *Manager) confirmWorkshopIDs(candidates []AdoptCandidate) {
+	var ids []string
+	for _, candidate := range candidates {
+		if candidate.Skip == "" && candidate.IDSource != IDGenerated {
+			ids = append(ids, candidate.ID)
+		}
+	}
+	if len(ids) == 0 {
+		return
+	}
+
+	workshopAddons, err := m.GetWorkshopAddonsInfo(ids)
+	for i := range candidates {
+		candidate := &candidates[i]
+		if candidate.Skip != "" || candidate.IDSource == IDGenerated {
+			continue
+		}
+
+		workshopAddon := workshopAddons[candidate.ID]
+		switch {
+		case workshopAddon == nil && err != nil:
+			candidate.Skip = fmt.Sprintf("couldn't confirm workshop id %s: %v", candidate.ID, err)
+		case workshopAddon == nil || !isGModItem(workshopAddon):
+			id := candidate.ID
+			candidate.ID, candidate.IDSource = candidate.localID, IDGenerated
+			if !validAddonID(candidate.ID) {
+				candidate.Skip = fmt.Sprintf("%s is not a Garry's Mod workshop item and no local id can be derived", id)
+			}
+		}
+	}
+}
+
+// isGModItem reports whether a workshop item belongs to Garry's Mod. Items
+// cached before the app was recorded are assumed to.
+func isGModItem(workshopAddon *WorkshopAddon) bool {
+	return workshopAddon.ConsumerAppID == 0 || workshopAddon.ConsumerAppID == gmodAppID
+}
+
+func (m *Manager) planAdoptFolder(path string) AdoptCandidate {
+	name := filepath.Base(path)
+	candidate := AdoptCandidate{Path: path, Kind: AdoptFolder, Title: name}
+
+	meta, err := file.ReadAddonJSON(path)
+	if err == nil && meta.Title != "" {
+		candidate.Title = meta.Title
+	}
+
+	candidate.localID = localIDPrefix + slugify(name)
+	if id, ok := idFromName(name); ok {
+		candidate.ID, candidate.IDSource = id, IDFromName
+	} else if id := readWorkshopID(path); id != "" {
+		candidate.ID, candidate.IDSource = id, IDFromAddonJSON
+	} else {
+		candidate.ID, candidate.IDSource = candidate.localID, IDGenerated
+	}
+
+	if !validAddonID(candidate.ID) {
+		candidate.Skip = fmt.Sprintf("can't derive an addon id from %q", name)
+	}
+	return candidate
+}
+
+func (m *Manager) planAdoptGMA(path string) AdoptCandidate {
+	name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	candidate := AdoptCandidate{Path: path, Kind: AdoptGMA, Title: name}
+
+	gma, err := file.OpenGMA(path)
+	if err != nil {
+		candidate.Skip = fmt.Sprintf("unreadable gma file: %v", err)
+		return candidate
+	}
+	gma.Close()
+	if gma.Name != "" {
+		candidate.Title = gma.Name
+	}
+
+	localID, err := localAddonID(path)
+	if err != nil {
+		candidate.Skip = err.Error()
+		return candidate
+	}
+	candidate.localID = localID
+
+	if id, ok := idFromName(name); ok {
+		candidate.ID, candidate.IDSource = id, IDFromName
+	} else if id := workshopIDFromJSON([]byte(gma.Description)); id != "" {
+		candidate.ID, candidate.IDSource = id, IDFromGMA
+	} else {
+		candidate.ID, candidate.IDSource = localID, IDGenerated
+	}
+	return candidate
+}
+
+// readWorkshopID reads the workshopid key some addon.json files carry
+func readWorkshopID(dir string) string {
+	data, err := os.ReadFile(filepath.Join(dir, file.AddonJSONName))
+	if err != nil {
+		return ""
+	}
+	return workshopIDFromJSON(data)
+}
+
+// workshopIDFromJSON reads a workshopid key, which may be a number or a
+// string, from addon.json or a gma description written from it
+func workshopIDFromJSON(data []byte) string {
+	var addonJSON struct {
+		WorkshopID json.RawMessage `json:"workshopid"`
+	}
+	if json.Unmarshal(data, &addonJSON) != nil {
+		return ""
+	}
+
+	id := strings.Trim(string(addonJSON.WorkshopID), `"`)
+	if _, err := strconv.ParseUint(id, 10, 64); err != nil || id == "0" {
+		return ""
+	}
+	return id
+}
+
+var slugRe = regexp.MustCompile(`[^a-z0-9]+`)
+
+// slugify turns a folder name into something usable in a local ID
+func slugify(name string) string {
+	return strings.Trim(slugRe.ReplaceAllString(strings.ToLower(name), "-"), "-")
+}
+
+// Adopt moves the addons planned by PlanAdopt into OutDir and enables them
+// through symlinks, skipping candidates with a Skip reason. It returns an
+// error for every candidate that failed, keyed by path.
+func (m *Manager) Adopt(candidates []AdoptCandidate) (map[string]error, error) {
+	unlock, err := m.lock()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	failed := make(map[string]error)
+	for _, candidate := range candidates {
+		if candidate.Skip != "" {
+			continue
+		}
+
+		var err error
+		switch candidate.Kind {
+		case AdoptFolder:
+			err = m.adoptFolder(candidate)
+		case AdoptGMA:
+			err = m.adoptGMA(candidate)
+		default:
+			err = fmt.Errorf("unknown kind %q", candidate.Kind)
+		}
+		if err != nil {
+			failed[candidate.Path] = err
+			continue
+		}
+		m.log(fmt.Sprintf("Adopted %s as addon %s.", filepath.Base(candidate.Path), candidate.ID))
+	}
+
+	return failed, nil
+}
+
+// adoptFolder moves an extracted addon into OutDir and links it back
+func (m *Manager) adoptFolder(candidate AdoptCandidate) error {
+	outDir := filepath.Join(m.config.OutDir, candidate.ID)
+	if dirExists(outDir) {
+		return fmt.Errorf("addon %s is already installed", candidate.ID)
+	}
+	if err := os.MkdirAll(m.config.OutDir, 0755); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+	if err := os.Rename(candidate.Path, outDir); err != nil {
+		return fmt.Errorf("failed to move addon: %w", err)
+	}
+
+	link := filepath.Join(m.config.AddonDir, candidate.ID)
+	if err := os.Symlink(outDir, link); err != nil {
+		// Put the folder back so GMod keeps loading it
+		if restoreErr := os.Rename(outDir, candidate.Path); restoreErr != nil {
+			err = errors.Join(err, fmt.Errorf("failed to move addon back: %w", restoreErr))
+		}
+		return fmt.Errorf("failed to create symlink: %w", err)
+	}
+
+	entry, err := m.scanAddon(candidate.ID)
+	if err != nil {
+		return err
+	}
+	entry.Title = candidate.Title
+	entry.Source = SourceAdopted
+	entry.Enabled = true
+	return m.manifest.Put(entry)
+}
+
+// adoptGMA extracts a loose .gma like install-file would and removes it
+// from AddonDir, where GMod would otherwise load it a second time
+func (m *Manager) adoptGMA(candidate AdoptCandidate) error {
+	archive := filepath.Join(m.keptArchiveDir(candidate.ID), filepath.Base(candidate.Path))
+	if err := os.MkdirAll(filepath.Dir(archive), 0755); err != nil {
+		return fmt.Errorf("failed to create archive directory: %w", err)
+	}
+	if err := os.Rename(candidate.Path, archive); err != nil {
+		return fmt.Errorf("failed to move gma file: %w", err)
+	}
+
+	if err := m.installDownloaded(candidate.ID, archive, SourceAdopted); err != nil {
+		if restoreErr := os.Rename(archive, candidate.Path); restoreErr != nil {
+			err = errors.Join(err, fmt.Errorf("failed to move gma file back: %w", restoreErr))
+		}
+		return err
+	}
+
+	// Keep the time the file was put there rather than when it was adopted
+	if info, err := os.Stat(archive); err == nil {
+		m.manifest.Update(candidate.ID, func(entry *ManifestEntry) {
+			entry.InstalledAt = info.ModTime()
+		})
+	}
+	return nil
+}
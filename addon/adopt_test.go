@@ -0,0 +1,201 @@
+package addon
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"gmod-addon-manager/file"
+)
+
+func TestIDFromName(t *testing.T) {
+	tests := []struct {
+		name string
+		id   string
+	}{
+		{"104691717", "104691717"},
+		{"104691717-wiremod", "104691717"},
+		{"wiremod_104691717", "104691717"},
+		{"wiremod-104691717", "104691717"},
+		{"12345678", "12345678"},
+		{"2019-maps", ""},
+		{"maps-2019", ""},
+		{"hud_2048", ""},
+		{"1234567-short", ""},
+		{"wiremod", ""},
+		{"99999999999999999999999", ""}, // overflows a published file ID
+	}
+	for _, tt := range tests {
+		id, ok := idFromName(tt.name)
+		if id != tt.id || ok != (tt.id != "") {
+			t.Errorf("idFromName(%q) = %q, %v, want %q", tt.name, id, ok, tt.id)
+		}
+	}
+}
+
+// serveWorkshop points the published file details endpoint at handler
+func serveWorkshop(t *testing.T, handler http.Handler) {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	saved := publishedFileDetailsURL
+	publishedFileDetailsURL = server.URL
+	t.Cleanup(func() { publishedFileDetailsURL = saved })
+}
+
+// workshopItems serves the details of items, keyed by ID with their
+// consumer app, and reports every other ID as unknown
+func workshopItems(items map[string]int) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		count, _ := strconv.Atoi(r.FormValue("itemcount"))
+		var result WorkshopResponse
+		for i := range count {
+			id := r.FormValue("publishedfileids[" + strconv.Itoa(i) + "]")
+			details := WorkshopAddon{PublishedFileID: id, Result: 9}
+			if app, ok := items[id]; ok {
+				details = WorkshopAddon{PublishedFileID: id, Result: resultOK, ConsumerAppID: app, Title: "Item " + id}
+			}
+			result.Response.PublishedFileDetails = append(result.Response.PublishedFileDetails, details)
+		}
+		json.NewEncoder(w).Encode(result)
+	})
+}
+
+// writeRawGMA writes an archive without files whose description is stored
+// as-is, the way tools other than gmad may write it
+func writeRawGMA(t *testing.T, path, name, description string) {
+	t.Helper()
+	var buf bytes.Buffer
+	buf.WriteString(file.GMAIdent)
+	buf.WriteByte(file.GMAVersion)
+	binary.Write(&buf, binary.LittleEndian, uint64(0)) // steamid
+	binary.Write(&buf, binary.LittleEndian, uint64(0)) // timestamp
+	for _, s := range []string{"", name, description, "author"} {
+		buf.WriteString(s)
+		buf.WriteByte(0)
+	}
+	binary.Write(&buf, binary.LittleEndian, int32(1))  // addon version
+	binary.Write(&buf, binary.LittleEndian, uint32(0)) // end of file table
+	binary.Write(&buf, binary.LittleEndian, uint32(0)) // crc
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func mkdirAddon(t *testing.T, m *Manager, name, addonJSON string) {
+	t.Helper()
+	dir := filepath.Join(m.config.AddonDir, name)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if addonJSON != "" {
+		if err := os.WriteFile(filepath.Join(dir, file.AddonJSONName), []byte(addonJSON), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestPlanAdopt(t *testing.T) {
+	m := newTestManager(t)
+	serveWorkshop(t, workshopItems(map[string]int{
+		"104691717": 4000,
+		"222222222": 4000,
+		"333333333": 4000,
+		"440000000": 440, // another game's item
+	}))
+
+	mkdirAddon(t, m, "104691717-wiremod", "")
+	mkdirAddon(t, m, "hud_2048", "")
+	mkdirAddon(t, m, "weapons", `{"title": "Weapons", "workshopid": 222222222}`)
+	mkdirAddon(t, m, "99999999-unknown", "")
+	mkdirAddon(t, m, "tf2_440000000", "")
+	mkdirAddon(t, m, "wiremod_104691717", "")
+	writeRawGMA(t, filepath.Join(m.config.AddonDir, "packed.gma"), "Packed", `{"description": "", "workshopid": "333333333"}`)
+
+	candidates, err := m.PlanAdopt()
+	if err != nil {
+		t.Fatalf("PlanAdopt: %v", err)
+	}
+
+	type plan struct{ id, source, skip string }
+	want := map[string]plan{
+		"104691717-wiremod": {"104691717", IDFromName, ""},
+		"hud_2048":          {"local-hud-2048", IDGenerated, ""},
+		"weapons":           {"222222222", IDFromAddonJSON, ""},
+		"99999999-unknown":  {"local-99999999-unknown", IDGenerated, ""},
+		"tf2_440000000":     {"local-tf2-440000000", IDGenerated, ""},
+		"wiremod_104691717": {"104691717", IDFromName, "another addon is adopted as 104691717"},
+		"packed.gma":        {"333333333", IDFromGMA, ""},
+	}
+	if len(candidates) != len(want) {
+		t.Errorf("got %d candidates, want %d", len(candidates), len(want))
+	}
+	for _, c := range candidates {
+		name := filepath.Base(c.Path)
+		if got := (plan{c.ID, c.IDSource, c.Skip}); got != want[name] {
+			t.Errorf("%s planned as %+v, want %+v", name, got, want[name])
+		}
+	}
+}
+
+func TestPlanAdoptUnconfirmed(t *testing.T) {
+	m := newTestManager(t)
+	serveWorkshop(t, http.NotFoundHandler())
+
+	mkdirAddon(t, m, "104691717-wiremod", "")
+	mkdirAddon(t, m, "wiremod", "")
+
+	candidates, err := m.PlanAdopt()
+	if err != nil {
+		t.Fatalf("PlanAdopt: %v", err)
+	}
+
+	// Without the workshop, names that look like IDs aren't trusted
+	for _, c := range candidates {
+		switch filepath.Base(c.Path) {
+		case "104691717-wiremod":
+			if c.Skip == "" {
+				t.Errorf("adopting %s without confirming its id", c.Path)
+			}
+		case "wiremod":
+			if c.Skip != "" || c.ID != "local-wiremod" {
+				t.Errorf("wiremod planned as %+v, want local-wiremod", c)
+			}
+		}
+	}
+}
+
+func TestAdoptUpperCaseGMA(t *testing.T) {
+	m := newTestManager(t)
+	serveWorkshop(t, workshopItems(nil))
+
+	gma, err := os.ReadFile(writeTestGMA(t, "v1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(m.config.AddonDir, "PACKED.GMA"), gma, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	candidates, err := m.PlanAdopt()
+	if err != nil {
+		t.Fatalf("PlanAdopt: %v", err)
+	}
+	if len(candidates) != 1 || candidates[0].Kind != AdoptGMA || candidates[0].Skip != "" {
+		t.Fatalf("candidates = %+v, want one gma to adopt", candidates)
+	}
+	failed, err := m.Adopt(candidates)
+	if err != nil || len(failed) != 0 {
+		t.Fatalf("Adopt: %v, %v", failed, err)
+	}
+	if got := readInstalledLua(t, m, candidates[0].ID); got != "v1" {
+		t.Errorf("adopted contents = %q, want v1", got)
+	}
+}
@@ -37,10 +37,17 @@ func NewPersistentCache(ttl time.Duration) (*PersistentCache, error) {
 	}, nil
 }
 
+// TTL returns how long entries stay fresh
+func (c *PersistentCache) TTL() time.Duration {
+	return c.ttl
+}
+
 func (c *PersistentCache) cacheFilePath(id string) string {
 	return filepath.Join(c.cacheDir, fmt.Sprintf("%s.json", id))
 }
 
+// Get returns the cached info of an addon. Expired entries are still
+// returned, marked Stale, so they can be used when a refetch fails.
 func (c *PersistentCache) Get(id string) (*WorkshopAddon, bool, error) {
 	cacheFile := c.cacheFilePath(id)
 
@@ -61,18 +68,27 @@ func (c *PersistentCache) Get(id string) (*WorkshopAddon, bool, error) {
 		return nil, false, fmt.Errorf("failed to parse cache entry: %w", err)
 	}
 
-	// Check if entry has expired
-	if time.Since(entry.Timestamp) > c.ttl {
-		// Remove expired cache file
-		if err := os.Remove(cacheFile); err != nil {
-			return nil, false, fmt.Errorf("failed to remove expired cache file: %w", err)
-		}
-		return nil, false, nil
+	if entry.WorkshopAddon == nil {
+		return nil, false, fmt.Errorf("failed to parse cache entry: no workshop addon")
 	}
 
+	// Keep expired entries until they are replaced or pruned
+	entry.WorkshopAddon.Stale = c.expired(entry)
 	return entry.WorkshopAddon, true, nil
 }
 
+func (c *PersistentCache) expired(entry CacheEntry) bool {
+	return time.Since(entry.Timestamp) > c.ttl
+}
+
+// Delete removes the cached info of an addon
+func (c *PersistentCache) Delete(id string) error {
+	if err := os.Remove(c.cacheFilePath(id)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove cache file: %w", err)
+	}
+	return nil
+}
+
 func (c *PersistentCache) Set(id string, workshopAddon *WorkshopAddon) error {
 	entry := CacheEntry{
 		WorkshopAddon: workshopAddon,
@@ -84,10 +100,176 @@ func (c *PersistentCache) Set(id string, workshopAddon *WorkshopAddon) error {
 		return fmt.Errorf("failed to marshal cache entry: %w", err)
 	}
 
-	cacheFile := c.cacheFilePath(id)
-	if err := os.WriteFile(cacheFile, data, 0644); err != nil {
+	// Write to a temporary file and rename it so concurrent readers never
+	// see a partially written entry
+	tmpFile, err := os.CreateTemp(c.cacheDir, id+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to write cache file: %w", err)
+	}
+	defer os.Remove(tmpFile.Name())
+
+	if _, err := tmpFile.Write(data); err != nil {
+		tmpFile.Close()
+		return fmt.Errorf("failed to write cache file: %w", err)
+	}
+	if err := tmpFile.Close(); err != nil {
+		return fmt.Errorf("failed to write cache file: %w", err)
+	}
+	if err := os.Rename(tmpFile.Name(), c.cacheFilePath(id)); err != nil {
 		return fmt.Errorf("failed to write cache file: %w", err)
 	}
 
 	return nil
 }
+
+// CacheStats describes what is in the cache
+type CacheStats struct {
+	Dir     string
+	TTL     time.Duration
+	Entries int
+	Fresh   int
+	Stale   int   // expired, used only when the workshop can't be reached
+	Corrupt int   // unreadable entries and leftover temporary files
+	Size    int64 // bytes on disk
+	Oldest  time.Time
+	Newest  time.Time
+}
+
+// tmpFileGrace is how old a temporary file must be before it is taken for
+// a leftover. Younger ones may be a Set in progress, in this process or in
+// another one using the same cache.
+const tmpFileGrace = 10 * time.Second
+
+// cacheFile is an entry found while walking the cache directory
+type cacheFile struct {
+	path  string
+	size  int64
+	entry *CacheEntry // nil when the file can't be read as an entry
+}
+
+// files lists every file in the cache directory
+func (c *PersistentCache) files() ([]cacheFile, error) {
+	dirEntries, err := os.ReadDir(c.cacheDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cache directory: %w", err)
+	}
+
+	var files []cacheFile
+	for _, dirEntry := range dirEntries {
+		if dirEntry.IsDir() {
+			continue
+		}
+		info, err := dirEntry.Info()
+		if err != nil {
+			continue
+		}
+		f := cacheFile{path: filepath.Join(c.cacheDir, dirEntry.Name()), size: info.Size()}
+
+		// Temporary files left behind by an interrupted Set stay nil, and
+		// those of a Set that may still be writing are left out
+		if filepath.Ext(dirEntry.Name()) != ".json" {
+			if time.Since(info.ModTime()) < tmpFileGrace {
+				continue
+			}
+		} else {
+			var entry CacheEntry
+			if data, err := os.ReadFile(f.path); err == nil && json.Unmarshal(data, &entry) == nil && entry.WorkshopAddon != nil {
+				f.entry = &entry
+			}
+		}
+		files = append(files, f)
+	}
+	return files, nil
+}
+
+// Stats counts the cache entries by state
+func (c *PersistentCache) Stats() (CacheStats, error) {
+	stats := CacheStats{Dir: c.cacheDir, TTL: c.ttl}
+	files, err := c.files()
+	if err != nil {
+		return stats, err
+	}
+
+	for _, f := range files {
+		stats.Size += f.size
+		if f.entry == nil {
+			stats.Corrupt++
+			continue
+		}
+
+		stats.Entries++
+		if c.expired(*f.entry) {
+			stats.Stale++
+		} else {
+			stats.Fresh++
+		}
+		if stats.Oldest.IsZero() || f.entry.Timestamp.Before(stats.Oldest) {
+			stats.Oldest = f.entry.Timestamp
+		}
+		if f.entry.Timestamp.After(stats.Newest) {
+			stats.Newest = f.entry.Timestamp
+		}
+	}
+	return stats, nil
+}
+
+// Clear removes every cache entry, returning how many files were removed
+func (c *PersistentCache) Clear() (int, error) {
+	return c.removeFiles(func(cacheFile) bool { return true })
+}
+
+// Prune removes entries older than maxAge along with unreadable ones,
+// returning how many files were removed
+func (c *PersistentCache) Prune(maxAge time.Duration) (int, error) {
+	return c.removeFiles(func(f cacheFile) bool {
+		return f.entry == nil || time.Since(f.entry.Timestamp) > maxAge
+	})
+}
+
+func (c *PersistentCache) removeFiles(remove func(cacheFile) bool) (int, error) {
+	files, err := c.files()
+	if err != nil {
+		return 0, err
+	}
+
+	removed := 0
+	for _, f := range files {
+		if !remove(f) {
+			continue
+		}
+		if err := os.Remove(f.path); err != nil && !os.IsNotExist(err) {
+			return removed, fmt.Errorf("failed to remove cache file: %w", err)
+		}
+		removed++
+	}
+	return removed, nil
+}
+
+// Cache returns the workshop info cache
+func (m *Manager) Cache() *PersistentCache {
+	return m.cache
+}
+
+// WarmCache fetches the workshop info of every installed addon whose cache
+// entry is missing or expired, so it is available offline later. It
+// returns how many addons were fetched.
+func (m *Manager) WarmCache() (int, error) {
+	entries, err := os.ReadDir(m.config.OutDir)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read out directory: %w", err)
+	}
+
+	var ids []string
+	for _, entry := range entries {
+		if !isAddonDir(entry) || !isWorkshopID(entry.Name()) {
+			continue
+		}
+		if cached, found, err := m.cache.Get(entry.Name()); err == nil && found && !cached.Stale {
+			continue
+		}
+		ids = append(ids, entry.Name())
+	}
+
+	workshopAddons, err := m.RefreshWorkshopAddonsInfo(ids)
+	return len(workshopAddons), err
+}
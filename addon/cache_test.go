@@ -0,0 +1,154 @@
+package addon
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func newTestCache(t *testing.T) *PersistentCache {
+	t.Helper()
+	return &PersistentCache{cacheDir: t.TempDir(), ttl: time.Hour}
+}
+
+// writeCacheEntry stores an entry written at the given time
+func writeCacheEntry(t *testing.T, c *PersistentCache, id string, at time.Time) {
+	t.Helper()
+	data, err := json.Marshal(CacheEntry{WorkshopAddon: &WorkshopAddon{PublishedFileID: id, Title: "Addon " + id}, Timestamp: at})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(c.cacheFilePath(id), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// writeCacheFile writes a raw file into the cache directory, modified at
+func writeCacheFile(t *testing.T, c *PersistentCache, name, content string, at time.Time) {
+	t.Helper()
+	path := filepath.Join(c.cacheDir, name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(path, at, at); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPersistentCacheGet(t *testing.T) {
+	c := newTestCache(t)
+
+	if _, found, err := c.Get("111"); found || err != nil {
+		t.Errorf("Get on an empty cache = %v, %v, want not found", found, err)
+	}
+
+	if err := c.Set("111", &WorkshopAddon{PublishedFileID: "111", Title: "Fresh"}); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	cached, found, err := c.Get("111")
+	if err != nil || !found || cached.Title != "Fresh" || cached.Stale {
+		t.Errorf("Get after Set = %+v, %v, %v, want a fresh entry", cached, found, err)
+	}
+
+	// Expired entries are kept, marked stale
+	writeCacheEntry(t, c, "222", time.Now().Add(-2*time.Hour))
+	cached, found, err = c.Get("222")
+	if err != nil || !found || !cached.Stale {
+		t.Errorf("Get of an expired entry = %+v, %v, %v, want a stale entry", cached, found, err)
+	}
+
+	writeCacheFile(t, c, "333.json", "{not json", time.Now())
+	if _, _, err := c.Get("333"); err == nil {
+		t.Error("Get of a corrupt entry succeeded")
+	}
+	writeCacheFile(t, c, "444.json", `{"Timestamp": "2024-01-01T00:00:00Z"}`, time.Now())
+	if _, _, err := c.Get("444"); err == nil {
+		t.Error("Get of an entry without workshop info succeeded")
+	}
+
+	if err := c.Delete("111"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, found, _ := c.Get("111"); found {
+		t.Error("deleted entry was found")
+	}
+	if err := c.Delete("111"); err != nil {
+		t.Errorf("deleting a missing entry: %v", err)
+	}
+}
+
+// fillTestCache writes a fresh, an expired and a corrupt entry, along with
+// a leftover temporary file and one that may still be written
+func fillTestCache(t *testing.T) *PersistentCache {
+	t.Helper()
+	c := newTestCache(t)
+	writeCacheEntry(t, c, "111", time.Now())
+	writeCacheEntry(t, c, "222", time.Now().Add(-2*time.Hour))
+	writeCacheFile(t, c, "333.json", "{not json", time.Now())
+	writeCacheFile(t, c, "444.123.tmp", "{", time.Now().Add(-time.Minute))
+	writeCacheFile(t, c, "555.456.tmp", "{", time.Now())
+	return c
+}
+
+func cacheFileNames(t *testing.T, c *PersistentCache) []string {
+	t.Helper()
+	entries, err := os.ReadDir(c.cacheDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	return names
+}
+
+func TestPersistentCacheStats(t *testing.T) {
+	c := fillTestCache(t)
+
+	stats, err := c.Stats()
+	if err != nil {
+		t.Fatalf("Stats: %v", err)
+	}
+	if stats.Entries != 2 || stats.Fresh != 1 || stats.Stale != 1 || stats.Corrupt != 2 {
+		t.Errorf("stats = %+v, want 1 fresh, 1 stale and 2 corrupt", stats)
+	}
+	if !stats.Oldest.Before(stats.Newest) {
+		t.Errorf("oldest %s isn't before newest %s", stats.Oldest, stats.Newest)
+	}
+}
+
+func TestPersistentCachePrune(t *testing.T) {
+	c := fillTestCache(t)
+
+	removed, err := c.Prune(c.TTL())
+	if err != nil {
+		t.Fatalf("Prune: %v", err)
+	}
+	if removed != 3 {
+		t.Errorf("Prune removed %d files, want 3", removed)
+	}
+
+	// The temporary file of a Set in progress is left alone
+	want := []string{"111.json", "555.456.tmp"}
+	if names := cacheFileNames(t, c); len(names) != len(want) || names[0] != want[0] || names[1] != want[1] {
+		t.Errorf("files after Prune = %v, want %v", names, want)
+	}
+}
+
+func TestPersistentCacheClear(t *testing.T) {
+	c := fillTestCache(t)
+
+	removed, err := c.Clear()
+	if err != nil {
+		t.Fatalf("Clear: %v", err)
+	}
+	if removed != 4 {
+		t.Errorf("Clear removed %d files, want 4", removed)
+	}
+	if names := cacheFileNames(t, c); len(names) != 1 || names[0] != "555.456.tmp" {
+		t.Errorf("files after Clear = %v, want only the temporary file in progress", names)
+	}
+}
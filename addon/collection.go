@@ -0,0 +1,169 @@
+package addon
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+)
+
+// collectionsFileName stores the last known members of each synced collection
+const collectionsFileName = "collections.json"
+
+// ResolveCollection returns the IDs of every item in a workshop collection,
+// following nested collections. Each item is listed once, in collection order.
+func (m *Manager) ResolveCollection(id string) ([]string, error) {
+	var members []string
+	seen := map[string]bool{id: true}
+	pending := []string{id}
+
+	for len(pending) > 0 {
+		var next []string
+		for start := 0; start < len(pending); start += workshopBatchSize {
+			batch := pending[start:min(start+workshopBatchSize, len(pending))]
+			children, err := m.fetchCollectionChildren(batch)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get collection details: %w", err)
+			}
+
+			for _, collectionID := range batch {
+				collectionChildren, ok := children[collectionID]
+				if !ok && collectionID == id {
+					return nil, fmt.Errorf("collection %s not found", id)
+				}
+
+				sort.SliceStable(collectionChildren, func(i, j int) bool {
+					return collectionChildren[i].SortOrder < collectionChildren[j].SortOrder
+				})
+				for _, child := range collectionChildren {
+					if seen[child.PublishedFileID] {
+						continue
+					}
+					seen[child.PublishedFileID] = true
+
+					if child.FileType == fileTypeCollection {
+						next = append(next, child.PublishedFileID)
+					} else {
+						members = append(members, child.PublishedFileID)
+					}
+				}
+			}
+		}
+		pending = next
+	}
+
+	return members, nil
+}
+
+// InstallCollection installs every member of a workshop collection that is
+// not installed yet. With sync set, installed members are enabled and
+// members removed from the collection since the last sync are disabled.
+func (m *Manager) InstallCollection(id string, sync bool) error {
+	unlock, err := m.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	m.log(fmt.Sprintf("Resolving collection %s...", id))
+	members, err := m.ResolveCollection(id)
+	if err != nil {
+		return err
+	}
+	m.log(fmt.Sprintf("Collection %s has %d item(s).", id, len(members)))
+
+	var errs []error
+	var missing []string
+	for _, member := range members {
+		addon := m.localAddonInfo(member)
+		if !addon.Installed {
+			missing = append(missing, member)
+			continue
+		}
+
+		if sync && !addon.Enabled {
+			if err := m.EnableAddon(member); err != nil {
+				errs = append(errs, fmt.Errorf("addon %s: %w", member, err))
+			}
+		}
+	}
+
+	// Download everything that is missing in one go
+	if len(missing) > 0 {
+		for _, result := range m.GetAddons(missing) {
+			if result.Err != nil {
+				errs = append(errs, fmt.Errorf("addon %s: %w", result.ID, result.Err))
+			}
+		}
+	}
+
+	collections, err := m.loadCollections()
+	if err != nil {
+		return err
+	}
+
+	if sync {
+		for _, previous := range collections[id] {
+			if slices.Contains(members, previous) {
+				continue
+			}
+			if addon := m.localAddonInfo(previous); addon.Enabled {
+				m.log(fmt.Sprintf("Addon %s was removed from collection %s.", previous, id))
+				if err := m.DisableAddon(previous); err != nil {
+					errs = append(errs, fmt.Errorf("addon %s: %w", previous, err))
+				}
+			}
+		}
+	}
+
+	collections[id] = members
+	if err := m.saveCollections(collections); err != nil {
+		return err
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("failed to install %d of %d addon(s): %w", len(errs), len(members), errors.Join(errs...))
+	}
+	return nil
+}
+
+// dataDir is where the manager keeps its own state next to the addons
+func (m *Manager) dataDir() string {
+	return filepath.Join(m.config.AddonDir, "0")
+}
+
+func (m *Manager) loadCollections() (map[string][]string, error) {
+	collections := make(map[string][]string)
+
+	data, err := os.ReadFile(filepath.Join(m.dataDir(), collectionsFileName))
+	if os.IsNotExist(err) {
+		return collections, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to read collections file: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &collections); err != nil {
+		return nil, fmt.Errorf("failed to parse collections file: %w", err)
+	}
+	return collections, nil
+}
+
+func (m *Manager) saveCollections(collections map[string][]string) error {
+	data, err := json.MarshalIndent(collections, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal collections: %w", err)
+	}
+
+	if err := os.MkdirAll(m.dataDir(), 0755); err != nil {
+		return fmt.Errorf("failed to create data directory: %w", err)
+	}
+	// Replace the file in one step so an interrupted sync can't truncate it
+	if err := writeFileAtomic(filepath.Join(m.dataDir(), collectionsFileName), bytes.NewReader(data)); err != nil {
+		return fmt.Errorf("failed to write collections file: %w", err)
+	}
+	return nil
+}
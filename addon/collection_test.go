@@ -0,0 +1,162 @@
+package addon
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"testing"
+)
+
+// collectionDetailsHandler answers GetCollectionDetails requests from the
+// given collections, counting the requests made
+func collectionDetailsHandler(t *testing.T, collections map[string][]CollectionChild, requests *int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Errorf("parsing request: %v", err)
+		}
+		*requests++
+
+		count, _ := strconv.Atoi(r.PostForm.Get("collectioncount"))
+		if count > workshopBatchSize {
+			t.Errorf("requested %d collections at once", count)
+		}
+		var response CollectionResponse
+		for i := range count {
+			id := r.PostForm.Get(fmt.Sprintf("publishedfileids[%d]", i))
+			details := CollectionDetails{PublishedFileID: id, Result: 9}
+			if children, ok := collections[id]; ok {
+				details = CollectionDetails{PublishedFileID: id, Result: resultOK, Children: children}
+			}
+			response.Response.CollectionDetails = append(response.Response.CollectionDetails, details)
+		}
+		json.NewEncoder(w).Encode(response)
+	}
+}
+
+func collectionItem(id string, order int) CollectionChild {
+	return CollectionChild{PublishedFileID: id, SortOrder: order}
+}
+
+func nestedCollection(id string, order int) CollectionChild {
+	return CollectionChild{PublishedFileID: id, SortOrder: order, FileType: fileTypeCollection}
+}
+
+func TestResolveCollection(t *testing.T) {
+	// A collection of 150 collections with one item each
+	wide := map[string][]CollectionChild{}
+	var wideWant []string
+	for i := range 150 {
+		id := strconv.Itoa(2000 + i)
+		wide["1"] = append(wide["1"], nestedCollection(id, i))
+		wide[id] = []CollectionChild{collectionItem(strconv.Itoa(3000+i), 0)}
+		wideWant = append(wideWant, strconv.Itoa(3000+i))
+	}
+
+	tests := []struct {
+		name        string
+		collections map[string][]CollectionChild
+		want        []string
+		requests    int
+		wantErr     bool
+	}{
+		{
+			name:        "flat",
+			collections: map[string][]CollectionChild{"1": {collectionItem("10", 0), collectionItem("11", 1)}},
+			want:        []string{"10", "11"},
+			requests:    1,
+		},
+		{
+			name:        "sort order",
+			collections: map[string][]CollectionChild{"1": {collectionItem("10", 2), collectionItem("11", 0), collectionItem("12", 1)}},
+			want:        []string{"11", "12", "10"},
+			requests:    1,
+		},
+		{
+			name: "nested",
+			collections: map[string][]CollectionChild{
+				"1": {collectionItem("10", 0), nestedCollection("2", 1), collectionItem("11", 2)},
+				"2": {collectionItem("20", 0), nestedCollection("3", 1)},
+				"3": {collectionItem("30", 0)},
+			},
+			want:     []string{"10", "11", "20", "30"},
+			requests: 3,
+		},
+		{
+			name: "duplicates and cycles",
+			collections: map[string][]CollectionChild{
+				"1": {collectionItem("10", 0), nestedCollection("2", 1)},
+				"2": {collectionItem("10", 0), collectionItem("20", 1), nestedCollection("1", 2)},
+			},
+			want:     []string{"10", "20"},
+			requests: 2,
+		},
+		{
+			name: "unavailable nested collection",
+			collections: map[string][]CollectionChild{
+				"1": {collectionItem("10", 0), nestedCollection("2", 1)},
+			},
+			want:     []string{"10"},
+			requests: 2,
+		},
+		{
+			name:        "batched levels",
+			collections: wide,
+			want:        wideWant,
+			requests:    3,
+		},
+		{
+			name:        "not found",
+			collections: map[string][]CollectionChild{},
+			requests:    1,
+			wantErr:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		m := newTestManager(t)
+		var requests int
+		serveSteamAPI(t, &collectionDetailsURL, collectionDetailsHandler(t, tt.collections, &requests))
+
+		members, err := m.ResolveCollection("1")
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: ResolveCollection error = %v", tt.name, err)
+			continue
+		}
+		if !slices.Equal(members, tt.want) {
+			t.Errorf("%s: members = %v, want %v", tt.name, members, tt.want)
+		}
+		if requests != tt.requests {
+			t.Errorf("%s: made %d requests, want %d", tt.name, requests, tt.requests)
+		}
+	}
+}
+
+func TestSaveCollections(t *testing.T) {
+	m := newTestManager(t)
+	want := map[string][]string{"1": {"10", "11"}, "2": {"20"}}
+	if err := m.saveCollections(want); err != nil {
+		t.Fatalf("saveCollections: %v", err)
+	}
+
+	got, err := m.loadCollections()
+	if err != nil {
+		t.Fatalf("loadCollections: %v", err)
+	}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("collections = %v, want %v", got, want)
+	}
+
+	// Only the collections file is left behind
+	entries, err := os.ReadDir(m.dataDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range entries {
+		if entry.Name() != collectionsFileName && entry.Name() != "out" {
+			t.Errorf("unexpected file %s in the data directory", entry.Name())
+		}
+	}
+}
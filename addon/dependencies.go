@@ -0,0 +1,130 @@
+package addon
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ResolveDependencies returns the install plan for a set of addons: every
+// item they require, recursively, with each addon following its own
+// requirements. Dependencies always come before the items that need them,
+// each item is listed once and cycles are broken arbitrarily.
+func (m *Manager) ResolveDependencies(ids []string) ([]string, error) {
+	// Walk the dependency graphs of all the addons together, level by level,
+	// so each level costs one request per batch
+	required := make(map[string][]string)
+	seen := make(map[string]bool, len(ids))
+	var pending []string
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			pending = append(pending, id)
+		}
+	}
+	for len(pending) > 0 {
+		var next []string
+		for start := 0; start < len(pending); start += workshopBatchSize {
+			batch := pending[start:min(start+workshopBatchSize, len(pending))]
+			children, err := m.fetchCollectionChildren(batch)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get required items: %w", err)
+			}
+
+			for _, itemID := range batch {
+				required[itemID] = []string{}
+				for _, child := range children[itemID] {
+					if child.FileType == fileTypeCollection {
+						continue
+					}
+					required[itemID] = append(required[itemID], child.PublishedFileID)
+					if !seen[child.PublishedFileID] {
+						seen[child.PublishedFileID] = true
+						next = append(next, child.PublishedFileID)
+					}
+				}
+			}
+		}
+		pending = next
+	}
+
+	// Depth-first post-order gives dependencies before dependents
+	var plan []string
+	visited := make(map[string]bool)
+	var visit func(itemID string)
+	visit = func(itemID string) {
+		if visited[itemID] {
+			return
+		}
+		visited[itemID] = true
+		for _, dep := range required[itemID] {
+			visit(dep)
+		}
+		plan = append(plan, itemID)
+	}
+	for _, id := range ids {
+		visit(id)
+	}
+
+	return plan, nil
+}
+
+// installPlan returns the addons to install for a request, dependencies
+// first. Dependencies that are already installed are left out, while
+// requested addons are always (re)installed.
+func (m *Manager) installPlan(ids []string) ([]string, map[string]bool) {
+	requested := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		requested[id] = true
+	}
+
+	// Resolve every addon in one walk, so shared requirements are only
+	// looked up once
+	fullPlan := ids
+	if !m.noDeps {
+		resolved, err := m.ResolveDependencies(ids)
+		if err != nil {
+			m.log(fmt.Sprintf("Warning: could not resolve dependencies: %v", err))
+		} else {
+			fullPlan = resolved
+		}
+	}
+
+	var plan []string
+	planned := make(map[string]bool)
+	for _, itemID := range fullPlan {
+		if planned[itemID] {
+			continue
+		}
+		if !requested[itemID] && m.localAddonInfo(itemID).Installed {
+			m.log(fmt.Sprintf("Dependency %s is already installed.", itemID))
+			continue
+		}
+		planned[itemID] = true
+		plan = append(plan, itemID)
+	}
+
+	if len(plan) > len(ids) {
+		m.log(m.describePlan(plan, requested))
+	}
+	return plan, requested
+}
+
+// describePlan formats an install plan with workshop titles where known
+func (m *Manager) describePlan(plan []string, requested map[string]bool) string {
+	workshopAddons, _ := m.GetWorkshopAddonsInfo(plan)
+
+	var sb strings.Builder
+	sb.WriteString("Install plan:")
+	for i, itemID := range plan {
+		title := ""
+		if workshopAddon := workshopAddons[itemID]; workshopAddon != nil {
+			title = " - " + workshopAddon.Title
+		}
+		role := "dependency"
+		if requested[itemID] {
+			role = "requested"
+		}
+		fmt.Fprintf(&sb, "\n  %d. %s%s (%s)", i+1, itemID, title, role)
+	}
+	return sb.String()
+}
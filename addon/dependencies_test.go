@@ -0,0 +1,179 @@
+package addon
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"testing"
+)
+
+func TestResolveDependencies(t *testing.T) {
+	// 150 addons without requirements take two batches
+	var many []string
+	for i := range 150 {
+		many = append(many, strconv.Itoa(5000+i))
+	}
+
+	tests := []struct {
+		name     string
+		ids      []string
+		required map[string][]CollectionChild
+		want     []string
+		requests int
+	}{
+		{
+			name:     "no requirements",
+			ids:      []string{"1"},
+			want:     []string{"1"},
+			requests: 1,
+		},
+		{
+			name: "chain",
+			ids:  []string{"1"},
+			required: map[string][]CollectionChild{
+				"1": {collectionItem("2", 0)},
+				"2": {collectionItem("3", 0)},
+			},
+			want:     []string{"3", "2", "1"},
+			requests: 3,
+		},
+		{
+			name: "diamond",
+			ids:  []string{"1"},
+			required: map[string][]CollectionChild{
+				"1": {collectionItem("2", 0), collectionItem("3", 1)},
+				"2": {collectionItem("4", 0)},
+				"3": {collectionItem("4", 0)},
+			},
+			want:     []string{"4", "2", "3", "1"},
+			requests: 3,
+		},
+		{
+			name: "cycle",
+			ids:  []string{"1"},
+			required: map[string][]CollectionChild{
+				"1": {collectionItem("2", 0)},
+				"2": {collectionItem("1", 0)},
+			},
+			want:     []string{"2", "1"},
+			requests: 2,
+		},
+		{
+			name: "collections are not requirements",
+			ids:  []string{"1"},
+			required: map[string][]CollectionChild{
+				"1": {nestedCollection("9", 0), collectionItem("2", 1)},
+			},
+			want:     []string{"2", "1"},
+			requests: 2,
+		},
+		{
+			name: "several addons share a walk",
+			ids:  []string{"1", "2", "1"},
+			required: map[string][]CollectionChild{
+				"1": {collectionItem("3", 0)},
+				"2": {collectionItem("3", 0), collectionItem("4", 1)},
+			},
+			want:     []string{"3", "1", "4", "2"},
+			requests: 2,
+		},
+		{
+			name: "requested addon required by another",
+			ids:  []string{"1", "2"},
+			required: map[string][]CollectionChild{
+				"1": {collectionItem("2", 0)},
+			},
+			want:     []string{"2", "1"},
+			requests: 1,
+		},
+		{
+			name:     "batched levels",
+			ids:      many,
+			want:     many,
+			requests: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		m := newTestManager(t)
+		var requests int
+		serveSteamAPI(t, &collectionDetailsURL, collectionDetailsHandler(t, tt.required, &requests))
+
+		plan, err := m.ResolveDependencies(tt.ids)
+		if err != nil {
+			t.Errorf("%s: ResolveDependencies: %v", tt.name, err)
+			continue
+		}
+		if !slices.Equal(plan, tt.want) {
+			t.Errorf("%s: plan = %v, want %v", tt.name, plan, tt.want)
+		}
+		if requests != tt.requests {
+			t.Errorf("%s: made %d requests, want %d", tt.name, requests, tt.requests)
+		}
+	}
+}
+
+func TestResolveDependenciesError(t *testing.T) {
+	m := newTestManager(t)
+	serveSteamAPI(t, &collectionDetailsURL, func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "busy", http.StatusServiceUnavailable)
+	})
+
+	if _, err := m.ResolveDependencies([]string{"1"}); err == nil {
+		t.Error("ResolveDependencies succeeded against a failing API")
+	}
+}
+
+func TestInstallPlan(t *testing.T) {
+	required := map[string][]CollectionChild{
+		"1": {collectionItem("3", 0)},
+		"2": {collectionItem("3", 0), collectionItem("4", 1)},
+	}
+
+	tests := []struct {
+		name     string
+		noDeps   bool
+		failing  bool
+		want     []string
+		requests int
+	}{
+		{name: "dependencies", want: []string{"3", "1", "2"}, requests: 2},
+		{name: "no dependencies", noDeps: true, want: []string{"1", "2"}},
+		{name: "unresolvable", failing: true, want: []string{"1", "2"}, requests: 1},
+	}
+
+	for _, tt := range tests {
+		m := newTestManager(t)
+		m.SetInstallDependencies(!tt.noDeps)
+
+		// Installed dependencies are left out
+		if err := os.MkdirAll(filepath.Join(m.config.OutDir, "4"), 0755); err != nil {
+			t.Fatal(err)
+		}
+
+		var requests int
+		handler := collectionDetailsHandler(t, required, &requests)
+		if tt.failing {
+			handler = func(w http.ResponseWriter, r *http.Request) {
+				requests++
+				http.Error(w, "busy", http.StatusServiceUnavailable)
+			}
+		}
+		serveSteamAPI(t, &collectionDetailsURL, handler)
+		var batches []int
+		serveSteamAPI(t, &publishedFileDetailsURL, publishedFileDetailsHandler(t, &batches, nil))
+
+		plan, requested := m.installPlan([]string{"1", "2"})
+		if !slices.Equal(plan, tt.want) {
+			t.Errorf("%s: plan = %v, want %v", tt.name, plan, tt.want)
+		}
+		if len(requested) != 2 || !requested["1"] || !requested["2"] {
+			t.Errorf("%s: requested = %v", tt.name, requested)
+		}
+		if requests != tt.requests {
+			t.Errorf("%s: made %d requests, want %d", tt.name, requests, tt.requests)
+		}
+	}
+}
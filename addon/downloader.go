@@ -0,0 +1,440 @@
+package addon
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"gmod-addon-manager/config"
+	"gmod-addon-manager/file"
+)
+
+// Downloader fetches a workshop item and returns the path of the
+// downloaded .gma or _legacy.bin file
+type Downloader interface {
+	Name() string
+	Download(ctx context.Context, id string) (string, error)
+}
+
+// BatchDownloader is a Downloader that can fetch many items in one session.
+// It returns the path of every item fetched and an error for every item
+// that wasn't.
+type BatchDownloader interface {
+	Downloader
+	DownloadAll(ctx context.Context, ids []string) (map[string]string, map[string]error)
+}
+
+// Downloader names accepted in config.Config.Downloaders
+const (
+	DownloaderSteamCmd = "steamcmd"
+	DownloaderHTTP     = "http"
+	DownloaderLocal    = "local"
+)
+
+// newDownloaders builds the downloader chain configured in cfg
+func (m *Manager) newDownloaders(cfg *config.Config) ([]Downloader, error) {
+	var downloaders []Downloader
+	for _, name := range cfg.Downloaders {
+		switch name {
+		case DownloaderSteamCmd:
+			downloaders = append(downloaders, &SteamCmdDownloader{
+				SteamCmdPath: cfg.SteamCmdPath,
+				DownloadDir:  cfg.DownloadDir,
+				OnEvent:      m.steamCmdEvent,
+			})
+		case DownloaderHTTP:
+			downloaders = append(downloaders, &HTTPDownloader{
+				Lookup:      m.lookupFileURL,
+				DownloadDir: m.httpDownloadDir(),
+				OnProgress: func(id string, done, total int64) {
+					m.progress(ProgressEvent{ID: id, Stage: StageDownload, Done: done, Total: total})
+				},
+			})
+		case DownloaderLocal:
+			if cfg.MirrorDir == "" {
+				return nil, fmt.Errorf("the %s downloader requires mirror_dir to be set", DownloaderLocal)
+			}
+			downloaders = append(downloaders, &LocalDownloader{Dir: cfg.MirrorDir})
+		default:
+			return nil, fmt.Errorf("unknown downloader %q", name)
+		}
+	}
+	return downloaders, nil
+}
+
+// SetDownloaders replaces the configured downloader chain
+func (m *Manager) SetDownloaders(downloaders ...Downloader) {
+	m.downloaders = downloaders
+}
+
+// download tries each downloader in order and returns the first file fetched
+func (m *Manager) download(ctx context.Context, id string) (string, error) {
+	paths, errs := m.downloadAll(ctx, []string{id})
+	if err, failed := errs[id]; failed {
+		return "", err
+	}
+	return paths[id], nil
+}
+
+// downloadAll downloads many items, passing the ones each downloader
+// couldn't fetch on to the next. Batch-capable downloaders get every
+// remaining item at once.
+func (m *Manager) downloadAll(ctx context.Context, ids []string) (map[string]string, map[string]error) {
+	paths := make(map[string]string, len(ids))
+	errs := make(map[string][]error)
+	remaining := ids
+
+	for _, downloader := range m.downloaders {
+		if len(remaining) == 0 || ctx.Err() != nil {
+			break
+		}
+
+		var failed []string
+		if batch, ok := downloader.(BatchDownloader); ok {
+			m.log(fmt.Sprintf("Downloading %d addon(s) (%s)...", len(remaining), downloader.Name()))
+			for _, id := range remaining {
+				m.progress(ProgressEvent{ID: id, Stage: StageDownload, Total: -1, Message: fmt.Sprintf("Queued for %s", downloader.Name())})
+			}
+			fetched, batchErrs := batch.DownloadAll(ctx, remaining)
+			for _, id := range remaining {
+				if path, ok := fetched[id]; ok {
+					paths[id] = path
+					continue
+				}
+				if batchErrs[id] == nil {
+					batchErrs[id] = fmt.Errorf("item was not downloaded")
+				}
+				failed = append(failed, id)
+				errs[id] = append(errs[id], fmt.Errorf("%s: %w", downloader.Name(), batchErrs[id]))
+			}
+		} else {
+			for _, id := range remaining {
+				m.log(fmt.Sprintf("Downloading addon %s (%s)...", id, downloader.Name()))
+				m.progress(ProgressEvent{ID: id, Stage: StageDownload, Total: -1, Message: fmt.Sprintf("Downloading via %s...", downloader.Name())})
+				path, err := downloader.Download(ctx, id)
+				if err != nil {
+					failed = append(failed, id)
+					errs[id] = append(errs[id], fmt.Errorf("%s: %w", downloader.Name(), err))
+					continue
+				}
+				paths[id] = path
+			}
+		}
+		remaining = failed
+	}
+
+	downloadErrs := make(map[string]error, len(remaining))
+	for _, id := range remaining {
+		if ctx.Err() != nil {
+			downloadErrs[id] = ctx.Err()
+			continue
+		}
+		if len(errs[id]) == 0 {
+			downloadErrs[id] = fmt.Errorf("no downloaders configured")
+			continue
+		}
+		downloadErrs[id] = fmt.Errorf("failed to download addon %s: %w", id, errors.Join(errs[id]...))
+	}
+	return paths, downloadErrs
+}
+
+// httpDownloadDir holds the files fetched by the HTTP downloader. It is
+// kept apart from steamcmd's download directory so neither backend touches
+// the other's files.
+func (m *Manager) httpDownloadDir() string {
+	return filepath.Join(m.dataDir(), "downloads")
+}
+
+// lookupFileURL returns fresh workshop details so expired file URLs aren't used
+func (m *Manager) lookupFileURL(id string) (*WorkshopAddon, error) {
+	workshopAddons, err := m.RefreshWorkshopAddonsInfo([]string{id})
+	if err != nil {
+		return nil, err
+	}
+	if workshopAddons[id] == nil {
+		return nil, fmt.Errorf("addon %s not found on the workshop", id)
+	}
+	return workshopAddons[id], nil
+}
+
+// firstDownloadedFile returns the first file in dir/<id>, the layout both
+// steamcmd and the HTTP downloader use
+func firstDownloadedFile(dir, id string) (string, error) {
+	downloadDir := filepath.Join(dir, id)
+
+	// Get the first file (should be either .gma or _legacy.bin)
+	downloadedFileName, err := file.First(downloadDir)
+	if err != nil {
+		return "", fmt.Errorf("failed to get the first file: %w", err)
+	}
+	return filepath.Join(downloadDir, downloadedFileName), nil
+}
+
+// steamCmdMaxLine is the longest line of steamcmd output that is parsed.
+// Progress bars redrawn with carriage returns can make very long lines.
+const steamCmdMaxLine = 1024 * 1024
+
+// SteamCmdDownloader downloads items anonymously through SteamCMD
+type SteamCmdDownloader struct {
+	SteamCmdPath string
+	DownloadDir  string // steamapps/workshop/content/4000 of the steamcmd install
+
+	// OnEvent receives the progress parsed from steamcmd output
+	OnEvent func(SteamCmdEvent)
+
+	// steamcmd can't run twice against the same install, so concurrent
+	// jobs take turns downloading
+	mu sync.Mutex
+}
+
+func (d *SteamCmdDownloader) Name() string {
+	return DownloaderSteamCmd
+}
+
+func (d *SteamCmdDownloader) Download(ctx context.Context, id string) (string, error) {
+	paths, errs := d.DownloadAll(ctx, []string{id})
+	if err, failed := errs[id]; failed {
+		return "", err
+	}
+	return paths[id], nil
+}
+
+// DownloadAll fetches every item in a single steamcmd session, paying the
+// startup and login cost once
+func (d *SteamCmdDownloader) DownloadAll(ctx context.Context, ids []string) (map[string]string, map[string]error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if err := ctx.Err(); err != nil {
+		return nil, failAll(ids, err)
+	}
+
+	args := []string{"+login", "anonymous"}
+	for _, id := range ids {
+		args = append(args, "+workshop_download_item", "4000", id)
+	}
+	args = append(args, "+quit")
+
+	steamCmd := exec.CommandContext(ctx, d.SteamCmdPath, args...)
+
+	// Output is parsed rather than passed through so it doesn't end up on
+	// the terminal (and over the TUI)
+	output, err := steamCmd.StdoutPipe()
+	if err != nil {
+		return nil, failAll(ids, fmt.Errorf("failed to capture steamcmd output: %w", err))
+	}
+	var stderr strings.Builder
+	steamCmd.Stderr = &stderr
+
+	if err := steamCmd.Start(); err != nil {
+		return nil, failAll(ids, fmt.Errorf("failed to run steamcmd: %w", err))
+	}
+
+	var loginErr error
+	results := make(map[string]SteamCmdEvent)
+	var lastLine string
+	scanner := bufio.NewScanner(output)
+	scanner.Buffer(make([]byte, 0, 64*1024), steamCmdMaxLine)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line != "" {
+			lastLine = line
+		}
+
+		event, ok := ParseSteamCmdLine(line)
+		if !ok {
+			continue
+		}
+		switch event.Type {
+		case SteamCmdLoginFailed:
+			loginErr = fmt.Errorf("steam login failed: %s", event.Reason)
+		case SteamCmdDownloadSuccess, SteamCmdDownloadFailed:
+			results[event.ID] = event
+		}
+		if d.OnEvent != nil {
+			d.OnEvent(event)
+		}
+	}
+
+	// Drain the rest of the output if reading stopped early, so steamcmd
+	// doesn't block on a full pipe
+	scanErr := scanner.Err()
+	if scanErr != nil {
+		io.Copy(io.Discard, output)
+	}
+
+	// steamcmd exits non-zero when any item fails, so the results are
+	// checked per item rather than failing the whole batch
+	runErr := steamCmd.Wait()
+	if ctx.Err() != nil {
+		runErr = ctx.Err()
+	} else if runErr != nil {
+		if detail := strings.TrimSpace(stderr.String()); detail != "" {
+			lastLine = detail
+		}
+		if lastLine != "" {
+			runErr = fmt.Errorf("%w: %s", runErr, lastLine)
+		}
+	}
+
+	// Only items steamcmd reported as downloaded count. The download
+	// directory may still hold a copy from an earlier session, which must
+	// not pass for a fresh one when steamcmd crashed or timed out.
+	paths := make(map[string]string, len(ids))
+	errs := make(map[string]error)
+	for _, id := range ids {
+		result, reported := results[id]
+		switch {
+		case reported && result.Type == SteamCmdDownloadFailed:
+			errs[id] = fmt.Errorf("download failed: %s", result.Reason)
+		case reported:
+			path, err := downloadedFile(result.Path, d.DownloadDir, id)
+			if err != nil {
+				errs[id] = err
+				continue
+			}
+			paths[id] = path
+		case loginErr != nil:
+			errs[id] = loginErr
+		case scanErr != nil:
+			errs[id] = fmt.Errorf("failed to read steamcmd output: %w", scanErr)
+		case runErr != nil:
+			errs[id] = fmt.Errorf("failed to run steamcmd: %w", runErr)
+		default:
+			errs[id] = fmt.Errorf("steamcmd did not report a result")
+		}
+	}
+	return paths, errs
+}
+
+// downloadedFile returns the file steamcmd reported downloading to dir,
+// looking in <downloadDir>/<id> when the reported directory has none
+func downloadedFile(dir, downloadDir, id string) (string, error) {
+	if dir != "" {
+		if name, err := file.First(dir); err == nil {
+			return filepath.Join(dir, name), nil
+		}
+	}
+	return firstDownloadedFile(downloadDir, id)
+}
+
+// failAll returns the same error for every item
+func failAll(ids []string, err error) map[string]error {
+	errs := make(map[string]error, len(ids))
+	for _, id := range ids {
+		errs[id] = err
+	}
+	return errs
+}
+
+// HTTPDownloader downloads items that have a public file_url, which is
+// the case for most legacy (pre-SteamPipe) workshop uploads
+type HTTPDownloader struct {
+	Lookup      func(id string) (*WorkshopAddon, error)
+	DownloadDir string // owned by this downloader, files go in <dir>/<id>
+	Client      *http.Client
+
+	// OnProgress receives the bytes downloaded so far, total is -1 when
+	// the server doesn't send a length
+	OnProgress func(id string, done, total int64)
+}
+
+func (d *HTTPDownloader) Name() string {
+	return DownloaderHTTP
+}
+
+func (d *HTTPDownloader) Download(ctx context.Context, id string) (string, error) {
+	workshopAddon, err := d.Lookup(id)
+	if err != nil {
+		return "", err
+	}
+	if workshopAddon.FileURL == "" {
+		return "", fmt.Errorf("addon %s has no public file url", id)
+	}
+
+	fileName := filepath.Base(workshopAddon.Filename)
+	if !isArchive(fileName) {
+		fileName = id + "_legacy.bin"
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, workshopAddon.FileURL, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+
+	client := d.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to download file: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to download file: %s", resp.Status)
+	}
+
+	downloadDir := filepath.Join(d.DownloadDir, id)
+	if err := os.MkdirAll(downloadDir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create download directory: %w", err)
+	}
+
+	var body io.Reader = resp.Body
+	if d.OnProgress != nil {
+		body = file.NewProgressReader(resp.Body, resp.ContentLength, func(done, total int64) {
+			d.OnProgress(id, done, total)
+		})
+	}
+
+	// Download next to the final name and rename once complete, so an
+	// interrupted download never looks like a finished one
+	path := filepath.Join(downloadDir, fileName)
+	if err := writeFileAtomic(path, body); err != nil {
+		return "", err
+	}
+
+	// Drop files of earlier versions that had another name
+	if entries, err := os.ReadDir(downloadDir); err == nil {
+		for _, entry := range entries {
+			if entry.Name() != fileName {
+				os.RemoveAll(filepath.Join(downloadDir, entry.Name()))
+			}
+		}
+	}
+
+	return path, nil
+}
+
+// LocalDownloader picks up items from a local mirror directory laid out as
+// <dir>/<id>/<file>, <dir>/<id>.gma or <dir>/<id>_legacy.bin
+type LocalDownloader struct {
+	Dir string
+}
+
+func (d *LocalDownloader) Name() string {
+	return DownloaderLocal
+}
+
+func (d *LocalDownloader) Download(ctx context.Context, id string) (string, error) {
+	for _, name := range []string{id + ".gma", id + "_legacy.bin"} {
+		path := filepath.Join(d.Dir, name)
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		}
+	}
+
+	path, err := firstDownloadedFile(d.Dir, id)
+	if err != nil {
+		return "", fmt.Errorf("addon %s not found in mirror %s", id, d.Dir)
+	}
+	return path, nil
+}
@@ -0,0 +1,387 @@
+package addon
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// fakeDownloader fetches the items it has a file for and records the
+// items it was asked for
+type fakeDownloader struct {
+	name  string
+	files map[string]string // id -> path
+	calls []string
+}
+
+func (d *fakeDownloader) Name() string {
+	return d.name
+}
+
+func (d *fakeDownloader) Download(ctx context.Context, id string) (string, error) {
+	d.calls = append(d.calls, id)
+	if path, ok := d.files[id]; ok {
+		return path, nil
+	}
+	return "", fmt.Errorf("%s has no %s", d.name, id)
+}
+
+// fakeBatchDownloader is a fakeDownloader fetching everything in one call.
+// Items in silent get neither a path nor an error.
+type fakeBatchDownloader struct {
+	fakeDownloader
+	batches [][]string
+	silent  map[string]bool
+}
+
+func (d *fakeBatchDownloader) DownloadAll(ctx context.Context, ids []string) (map[string]string, map[string]error) {
+	d.batches = append(d.batches, slices.Clone(ids))
+	paths := make(map[string]string)
+	errs := make(map[string]error)
+	for _, id := range ids {
+		switch path, ok := d.files[id]; {
+		case ok:
+			paths[id] = path
+		case !d.silent[id]:
+			errs[id] = fmt.Errorf("%s has no %s", d.name, id)
+		}
+	}
+	return paths, errs
+}
+
+func TestDownloadAllFallback(t *testing.T) {
+	m := newTestManager(t)
+	first := &fakeBatchDownloader{
+		fakeDownloader: fakeDownloader{name: "first", files: map[string]string{"1": "/first/1"}},
+		silent:         map[string]bool{"4": true},
+	}
+	second := &fakeDownloader{name: "second", files: map[string]string{"2": "/second/2", "1": "/second/1"}}
+	third := &fakeDownloader{name: "third", files: map[string]string{"3": "/third/3"}}
+	m.SetDownloaders(first, second, third)
+
+	paths, errs := m.downloadAll(context.Background(), []string{"1", "2", "3", "4"})
+
+	// Each item comes from the first downloader that has it
+	wantPaths := map[string]string{"1": "/first/1", "2": "/second/2", "3": "/third/3"}
+	if len(paths) != len(wantPaths) {
+		t.Errorf("paths = %v, want %v", paths, wantPaths)
+	}
+	for id, want := range wantPaths {
+		if paths[id] != want {
+			t.Errorf("paths[%s] = %q, want %q", id, paths[id], want)
+		}
+	}
+
+	// Only the items still missing are passed on
+	if len(first.batches) != 1 || !slices.Equal(first.batches[0], []string{"1", "2", "3", "4"}) {
+		t.Errorf("first got batches %v", first.batches)
+	}
+	if !slices.Equal(second.calls, []string{"2", "3", "4"}) {
+		t.Errorf("second was asked for %v", second.calls)
+	}
+	if !slices.Equal(third.calls, []string{"3", "4"}) {
+		t.Errorf("third was asked for %v", third.calls)
+	}
+
+	// The error of an item nobody had names every downloader tried
+	if len(errs) != 1 || errs["4"] == nil {
+		t.Fatalf("errs = %v, want only 4 to fail", errs)
+	}
+	msg := errs["4"].Error()
+	for _, want := range []string{"first: item was not downloaded", "second: second has no 4", "third: third has no 4"} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("error %q doesn't mention %q", msg, want)
+		}
+	}
+}
+
+func TestDownloadFallback(t *testing.T) {
+	m := newTestManager(t)
+	first := &fakeDownloader{name: "first", files: map[string]string{"1": "/first/1"}}
+	second := &fakeDownloader{name: "second", files: map[string]string{"1": "/second/1", "2": "/second/2"}}
+	m.SetDownloaders(first, second)
+
+	// Each item comes from the first downloader that has it
+	for _, tt := range []struct{ id, want string }{{"1", "/first/1"}, {"2", "/second/2"}} {
+		if path, err := m.download(context.Background(), tt.id); err != nil || path != tt.want {
+			t.Errorf("download(%s) = %q, %v, want %q", tt.id, path, err, tt.want)
+		}
+	}
+	if !slices.Equal(first.calls, []string{"1", "2"}) || !slices.Equal(second.calls, []string{"2"}) {
+		t.Errorf("downloaders were asked for %v and %v", first.calls, second.calls)
+	}
+
+	// The error of an item nobody has names every downloader tried
+	_, err := m.download(context.Background(), "3")
+	if err == nil {
+		t.Fatal("download of a missing item succeeded")
+	}
+	for _, want := range []string{"first: first has no 3", "second: second has no 3"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q doesn't mention %q", err, want)
+		}
+	}
+}
+
+func TestDownloadWithoutDownloaders(t *testing.T) {
+	m := newTestManager(t)
+	m.SetDownloaders()
+
+	if _, err := m.download(context.Background(), "1"); err == nil || !strings.Contains(err.Error(), "no downloaders configured") {
+		t.Errorf("download() error = %v, want no downloaders configured", err)
+	}
+}
+
+func TestDownloadCanceled(t *testing.T) {
+	m := newTestManager(t)
+	d := &fakeDownloader{name: "fake", files: map[string]string{"1": "/fake/1"}}
+	m.SetDownloaders(d)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := m.download(ctx, "1"); !errors.Is(err, context.Canceled) {
+		t.Errorf("download() error = %v, want context.Canceled", err)
+	}
+	if len(d.calls) != 0 {
+		t.Errorf("downloader was called after cancellation: %v", d.calls)
+	}
+}
+
+func newTestHTTPDownloader(t *testing.T, handler http.HandlerFunc) *HTTPDownloader {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	return &HTTPDownloader{
+		Lookup: func(id string) (*WorkshopAddon, error) {
+			return &WorkshopAddon{PublishedFileID: id, FileURL: server.URL + "/" + id, Filename: "addons/test.gma"}, nil
+		},
+		DownloadDir: t.TempDir(),
+		Client:      server.Client(),
+	}
+}
+
+func TestHTTPDownloader(t *testing.T) {
+	d := newTestHTTPDownloader(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("GMAD contents"))
+	})
+
+	// A file of an earlier version is replaced
+	stale := filepath.Join(d.DownloadDir, "111", "111_legacy.bin")
+	if err := os.MkdirAll(filepath.Dir(stale), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(stale, []byte("old"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	path, err := d.Download(context.Background(), "111")
+	if err != nil {
+		t.Fatalf("Download: %v", err)
+	}
+	if want := filepath.Join(d.DownloadDir, "111", "test.gma"); path != want {
+		t.Errorf("path = %q, want %q", path, want)
+	}
+	if data, err := os.ReadFile(path); err != nil || string(data) != "GMAD contents" {
+		t.Errorf("downloaded %q, %v", data, err)
+	}
+
+	entries, err := os.ReadDir(filepath.Dir(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("download directory holds %d files, want only the new one", len(entries))
+	}
+}
+
+func TestHTTPDownloaderTruncated(t *testing.T) {
+	d := newTestHTTPDownloader(t, func(w http.ResponseWriter, r *http.Request) {
+		// Promise more than is sent, so the client sees an unexpected EOF
+		w.Header().Set("Content-Length", "1000")
+		w.Write([]byte("GMAD"))
+	})
+
+	if _, err := d.Download(context.Background(), "111"); err == nil {
+		t.Fatal("Download of a truncated response succeeded")
+	}
+
+	// Nothing that could be mistaken for a download is left behind
+	entries, err := os.ReadDir(filepath.Join(d.DownloadDir, "111"))
+	if err != nil && !os.IsNotExist(err) {
+		t.Fatal(err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("truncated download left %d file(s)", len(entries))
+	}
+	if _, err := firstDownloadedFile(d.DownloadDir, "111"); err == nil {
+		t.Error("firstDownloadedFile found a truncated download")
+	}
+}
+
+func TestHTTPDownloaderStatus(t *testing.T) {
+	d := newTestHTTPDownloader(t, func(w http.ResponseWriter, r *http.Request) {
+		http.NotFound(w, r)
+	})
+
+	if _, err := d.Download(context.Background(), "111"); err == nil || !strings.Contains(err.Error(), "404") {
+		t.Errorf("Download error = %v, want the 404 status", err)
+	}
+}
+
+func TestLocalDownloader(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"111.gma", "222_legacy.bin", filepath.Join("333", "addon.gma")} {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	d := &LocalDownloader{Dir: dir}
+	for id, want := range map[string]string{
+		"111": filepath.Join(dir, "111.gma"),
+		"222": filepath.Join(dir, "222_legacy.bin"),
+		"333": filepath.Join(dir, "333", "addon.gma"),
+	} {
+		if path, err := d.Download(context.Background(), id); err != nil || path != want {
+			t.Errorf("Download(%s) = %q, %v, want %q", id, path, err, want)
+		}
+	}
+	if _, err := d.Download(context.Background(), "444"); err == nil {
+		t.Error("Download of a missing item succeeded")
+	}
+}
+
+// newFakeSteamCmd returns a downloader running a shell script in place of
+// steamcmd, see setSteamCmdOutput
+func newFakeSteamCmd(t *testing.T) *SteamCmdDownloader {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake steamcmd is a shell script")
+	}
+
+	dir := t.TempDir()
+	return &SteamCmdDownloader{
+		SteamCmdPath: filepath.Join(dir, "steamcmd.sh"),
+		DownloadDir:  filepath.Join(dir, "content", "4000"),
+	}
+}
+
+// setSteamCmdOutput makes the fake steamcmd print output and exit with code
+func setSteamCmdOutput(t *testing.T, d *SteamCmdDownloader, output string, code int) {
+	t.Helper()
+	script := fmt.Sprintf("#!/bin/sh\ncat <<'EOF'\n%s\nEOF\nexit %d\n", output, code)
+	if err := os.WriteFile(d.SteamCmdPath, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// writeDownload puts a file in the download directory of an item, as an
+// earlier session would have left it
+func writeDownload(t *testing.T, d *SteamCmdDownloader, id string) string {
+	t.Helper()
+	path := filepath.Join(d.DownloadDir, id, id+"_legacy.bin")
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte("data"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestSteamCmdDownloadAll(t *testing.T) {
+	d := newFakeSteamCmd(t)
+	fresh := writeDownload(t, d, "111")
+	writeDownload(t, d, "222") // stale, steamcmd says nothing about it
+	writeDownload(t, d, "333") // stale, steamcmd reports a failure
+
+	output := strings.Join([]string{
+		"Logging in user 'anonymous' to Steam Public...OK",
+		"Downloading item 111 ...",
+		fmt.Sprintf(`Success. Downloaded item 111 to "%s" (4 bytes)`, filepath.Dir(fresh)),
+		"Downloading item 333 ...",
+		"ERROR! Download item 333 failed (Timeout).",
+	}, "\n")
+	setSteamCmdOutput(t, d, output, 0)
+
+	var events []SteamCmdEvent
+	d.OnEvent = func(event SteamCmdEvent) { events = append(events, event) }
+
+	paths, errs := d.DownloadAll(context.Background(), []string{"111", "222", "333"})
+	if paths["111"] != fresh || errs["111"] != nil {
+		t.Errorf("111: path %q, err %v, want %q", paths["111"], errs["111"], fresh)
+	}
+	if _, ok := paths["222"]; ok || errs["222"] == nil {
+		t.Errorf("222 was taken from an earlier session: path %q", paths["222"])
+	}
+	if _, ok := paths["333"]; ok || errs["333"] == nil || !strings.Contains(errs["333"].Error(), "Timeout") {
+		t.Errorf("333: path %q, err %v, want the timeout", paths["333"], errs["333"])
+	}
+	if len(events) != 5 {
+		t.Errorf("got %d events, want 5", len(events))
+	}
+}
+
+func TestSteamCmdDownloadAllCrash(t *testing.T) {
+	d := newFakeSteamCmd(t)
+	setSteamCmdOutput(t, d, "Segmentation fault", 139)
+	writeDownload(t, d, "111")
+
+	paths, errs := d.DownloadAll(context.Background(), []string{"111"})
+	if len(paths) != 0 {
+		t.Errorf("paths = %v after steamcmd crashed", paths)
+	}
+	if err := errs["111"]; err == nil || !strings.Contains(err.Error(), "Segmentation fault") {
+		t.Errorf("err = %v, want the last line of output", err)
+	}
+}
+
+func TestSteamCmdDownloadAllLoginFailed(t *testing.T) {
+	d := newFakeSteamCmd(t)
+	setSteamCmdOutput(t, d, "Logging in user 'anonymous' to Steam Public...FAILED (No Connection)", 5)
+
+	_, errs := d.DownloadAll(context.Background(), []string{"111", "222"})
+	for _, id := range []string{"111", "222"} {
+		if err := errs[id]; err == nil || !strings.Contains(err.Error(), "No Connection") {
+			t.Errorf("%s: err = %v, want the login failure", id, err)
+		}
+	}
+}
+
+func TestSteamCmdDownloadAllLongLines(t *testing.T) {
+	d := newFakeSteamCmd(t)
+	path := writeDownload(t, d, "111")
+
+	// Lines over bufio.Scanner's default 64 KiB limit are still parsed
+	output := strings.Repeat("#", 100*1024) + "\n" +
+		fmt.Sprintf(`Success. Downloaded item 111 to "%s" (4 bytes)`, filepath.Dir(path))
+	setSteamCmdOutput(t, d, output, 0)
+
+	paths, errs := d.DownloadAll(context.Background(), []string{"111"})
+	if paths["111"] != path || errs["111"] != nil {
+		t.Errorf("path %q, err %v, want %q", paths["111"], errs["111"], path)
+	}
+
+	// Past the limit, the items that weren't reported fail with the reason
+	output = strings.Repeat("#", steamCmdMaxLine+1) + "\n" +
+		fmt.Sprintf(`Success. Downloaded item 111 to "%s" (4 bytes)`, filepath.Dir(path))
+	setSteamCmdOutput(t, d, output, 0)
+
+	paths, errs = d.DownloadAll(context.Background(), []string{"111"})
+	if err := errs["111"]; len(paths) != 0 || err == nil || !strings.Contains(err.Error(), "failed to read steamcmd output") {
+		t.Errorf("paths %v, err %v, want a read error", paths, err)
+	}
+}
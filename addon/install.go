@@ -0,0 +1,366 @@
+package addon
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gmod-addon-manager/file"
+)
+
+// installDownloaded extracts a downloaded .gma or _legacy.bin and swaps it
+// into OutDir. The new files are staged under TmpDir and validated first,
+// and the previous version is restored if any step fails.
+func (m *Manager) installDownloaded(id, downloadedFilePath, source string) error {
+	unlock := m.lockAddon(id)
+	defer unlock()
+
+	if err := m.recoverBackup(id); err != nil {
+		return err
+	}
+
+	// Start from a clean tmp directory in case an earlier install was interrupted
+	tmpDir := filepath.Join(m.config.TmpDir, id)
+	if err := os.RemoveAll(tmpDir); err != nil {
+		return fmt.Errorf("failed to clean up tmp directory: %w", err)
+	}
+	if err := os.MkdirAll(tmpDir, 0755); err != nil {
+		return fmt.Errorf("failed to create tmp directory: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	gmaPath := filepath.Join(tmpDir, id+".gma")
+	m.progress(ProgressEvent{ID: id, Stage: StageDecompress, Total: -1})
+	if err := prepareGMA(downloadedFilePath, gmaPath, m.stageProgress(id, StageDecompress)); err != nil {
+		return err
+	}
+
+	// Refuse to extract archives that were truncated or corrupted in transit
+	report, err := file.VerifyGMA(gmaPath)
+	if err != nil {
+		return fmt.Errorf("failed to verify gma file: %w", err)
+	}
+	if !report.OK() {
+		return fmt.Errorf("downloaded gma file is corrupt: %s", describeGMAReport(report))
+	}
+	if err := m.checkWhitelist(id, report.GMA); err != nil {
+		return err
+	}
+
+	m.log(fmt.Sprintf("Extracting addon %s...", id))
+	m.progress(ProgressEvent{ID: id, Stage: StageExtract, Total: report.GMA.Size()})
+	stageDir := filepath.Join(tmpDir, "stage")
+	if err := m.extractGMA(gmaPath, stageDir, m.stageProgress(id, StageExtract)); err != nil {
+		return err
+	}
+
+	// Make sure the extractor produced exactly what the archive contains
+	diff, err := file.CompareGMATree(report.GMA, stageDir)
+	if err != nil {
+		return err
+	}
+	if !diff.OK() {
+		return fmt.Errorf("extracted files don't match the archive (%d missing, %d extra, %d modified)",
+			len(diff.Missing), len(diff.Extra), len(diff.Modified))
+	}
+	m.log("Extraction completed.")
+
+	m.progress(ProgressEvent{ID: id, Stage: StageEnable, Total: -1})
+	restore, err := m.swapAddonDir(id, stageDir)
+	if err != nil {
+		return err
+	}
+
+	if err := m.finishInstall(id, source, downloadedFilePath, report.GMA); err != nil {
+		if restoreErr := restore(); restoreErr != nil {
+			return fmt.Errorf("%w (rollback failed: %v)", err, restoreErr)
+		}
+		return err
+	}
+
+	// The new version is in place, so the previous one can go
+	if err := os.RemoveAll(m.backupDir(id)); err != nil {
+		m.log(fmt.Sprintf("Warning: failed to remove backup of addon %s: %v", id, err))
+	}
+
+	m.log(fmt.Sprintf("Addon %s installed and enabled successfully.", id))
+	return nil
+}
+
+// InstallFromFile installs an addon from a local .gma, _legacy.bin or a zip
+// containing either. Without a workshop ID, a local ID is derived from the
+// archive contents. It returns the ID the addon was installed under.
+func (m *Manager) InstallFromFile(path, id string) (string, error) {
+	if id != "" && !validAddonID(id) {
+		return "", fmt.Errorf("invalid addon id %q", id)
+	}
+
+	unlock, err := m.lock()
+	if err != nil {
+		return "", err
+	}
+	defer unlock()
+
+	archive := path
+	if strings.EqualFold(filepath.Ext(path), ".zip") {
+		if err := os.MkdirAll(m.config.TmpDir, 0755); err != nil {
+			return "", fmt.Errorf("failed to create tmp directory: %w", err)
+		}
+		zipDir, err := os.MkdirTemp(m.config.TmpDir, "zip-")
+		if err != nil {
+			return "", fmt.Errorf("failed to create tmp directory: %w", err)
+		}
+		defer os.RemoveAll(zipDir)
+
+		m.log(fmt.Sprintf("Extracting %s...", filepath.Base(path)))
+		if err := file.ExtractZip(path, zipDir); err != nil {
+			return "", fmt.Errorf("failed to extract zip file: %w", err)
+		}
+		if archive, err = findArchive(zipDir); err != nil {
+			return "", fmt.Errorf("%s: %w", filepath.Base(path), err)
+		}
+	} else if !isArchive(path) {
+		return "", fmt.Errorf("unsupported file type: %s (expected .gma, _legacy.bin or .zip)", filepath.Base(path))
+	}
+
+	if id == "" {
+		if id, err = localAddonID(archive); err != nil {
+			return "", err
+		}
+	}
+
+	// The zip contents are temporary, so keep the archive for verify
+	finishKept := func(bool) {}
+	if archive != path {
+		if archive, finishKept, err = m.keepArchive(id, archive); err != nil {
+			return "", err
+		}
+	}
+
+	absArchive, err := filepath.Abs(archive)
+	if err != nil {
+		err = fmt.Errorf("failed to resolve path: %w", err)
+	} else {
+		err = m.installDownloaded(id, absArchive, SourceFile)
+	}
+	finishKept(err == nil)
+	if err != nil {
+		return "", err
+	}
+	return id, nil
+}
+
+// keptArchiveDir holds archives extracted from zips, which have nowhere
+// else to live
+func (m *Manager) keptArchiveDir(id string) string {
+	return filepath.Join(m.dataDir(), "files", id)
+}
+
+// keepArchive moves an archive extracted from a zip into keptArchiveDir.
+// The archives kept by an earlier install are set aside until finish is
+// called: a successful install drops them, a failed one puts them back.
+func (m *Manager) keepArchive(id, archive string) (string, func(ok bool), error) {
+	keptDir := m.keptArchiveDir(id)
+	previousDir := filepath.Join(filepath.Dir(keptDir), "."+id+".old")
+	if err := os.RemoveAll(previousDir); err != nil {
+		return "", nil, fmt.Errorf("failed to clean up archive directory: %w", err)
+	}
+	hadPrevious := dirExists(keptDir)
+	if hadPrevious {
+		if err := os.Rename(keptDir, previousDir); err != nil {
+			return "", nil, fmt.Errorf("failed to move previous archive aside: %w", err)
+		}
+	}
+
+	finish := func(ok bool) {
+		if ok {
+			os.RemoveAll(previousDir)
+			return
+		}
+		os.RemoveAll(keptDir)
+		if hadPrevious {
+			os.Rename(previousDir, keptDir)
+		}
+	}
+
+	kept := filepath.Join(keptDir, filepath.Base(archive))
+	if err := os.MkdirAll(keptDir, 0755); err != nil {
+		finish(false)
+		return "", nil, fmt.Errorf("failed to create archive directory: %w", err)
+	}
+	if err := os.Rename(archive, kept); err != nil {
+		finish(false)
+		return "", nil, fmt.Errorf("failed to keep archive: %w", err)
+	}
+	return kept, finish, nil
+}
+
+// localIDPrefix marks addons installed from a file without a workshop ID
+const localIDPrefix = "local-"
+
+// localAddonID derives a stable ID from an archive's contents, so
+// installing the same file twice replaces the first install
+func localAddonID(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to open archive: %w", err)
+	}
+	defer f.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, f); err != nil {
+		return "", fmt.Errorf("failed to read archive: %w", err)
+	}
+	return localIDPrefix + hex.EncodeToString(hash.Sum(nil))[:12], nil
+}
+
+// validAddonID reports whether id can be used as a folder name in
+// AddonDir without clashing with the manager's own data directory
+func validAddonID(id string) bool {
+	return id != "" && id != "0" && !strings.HasPrefix(id, ".") &&
+		!strings.ContainsAny(id, `/\:`)
+}
+
+// isArchive reports whether path is a file installDownloaded accepts
+func isArchive(path string) bool {
+	return hasSuffixFold(path, ".gma") || hasSuffixFold(path, "_legacy.bin")
+}
+
+// hasSuffixFold is strings.HasSuffix ignoring case, archives shared outside
+// the workshop are often named like ADDON.GMA
+func hasSuffixFold(s, suffix string) bool {
+	return len(s) >= len(suffix) && strings.EqualFold(s[len(s)-len(suffix):], suffix)
+}
+
+// findArchive returns the first .gma or _legacy.bin file below dir
+func findArchive(dir string) (string, error) {
+	var found string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() && isArchive(path) {
+			found = path
+			return fs.SkipAll
+		}
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to read zip contents: %w", err)
+	}
+	if found == "" {
+		return "", fmt.Errorf("no .gma or _legacy.bin file found")
+	}
+	return found, nil
+}
+
+// backupDir is where the previous version of an addon is kept while the new
+// one is moved into place. It sits next to the installed addons, so the
+// renames stay on one filesystem, and outside TmpDir, which is wiped at the
+// start of every install.
+func (m *Manager) backupDir(id string) string {
+	return filepath.Join(m.config.OutDir, "."+id+".bak")
+}
+
+// recoverBackup cleans up after an install that was interrupted during the
+// swap. If the previous version was moved aside but the new one never
+// arrived, the previous version is put back.
+func (m *Manager) recoverBackup(id string) error {
+	backupDir := m.backupDir(id)
+	if !dirExists(backupDir) {
+		return nil
+	}
+
+	outDir := filepath.Join(m.config.OutDir, id)
+	if dirExists(outDir) {
+		if err := os.RemoveAll(backupDir); err != nil {
+			return fmt.Errorf("failed to remove leftover backup: %w", err)
+		}
+		return nil
+	}
+
+	m.log(fmt.Sprintf("Restoring addon %s from an interrupted install...", id))
+	if err := os.Rename(backupDir, outDir); err != nil {
+		return fmt.Errorf("failed to restore leftover backup: %w", err)
+	}
+	return nil
+}
+
+// swapAddonDir moves stageDir into place as OutDir/<id>, keeping any
+// existing version in backupDir until the install is finished. The
+// returned function puts the previous version back.
+func (m *Manager) swapAddonDir(id, stageDir string) (func() error, error) {
+	outDir := filepath.Join(m.config.OutDir, id)
+	backupDir := m.backupDir(id)
+	if err := os.MkdirAll(m.config.OutDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	hadPrevious := dirExists(outDir)
+	if hadPrevious {
+		if err := os.Rename(outDir, backupDir); err != nil {
+			return nil, fmt.Errorf("failed to move previous version aside: %w", err)
+		}
+	}
+
+	restore := func() error {
+		if err := os.RemoveAll(outDir); err != nil {
+			return err
+		}
+		if hadPrevious {
+			return os.Rename(backupDir, outDir)
+		}
+		return nil
+	}
+
+	if err := os.Rename(stageDir, outDir); err != nil {
+		err = fmt.Errorf("failed to move addon into place: %w", err)
+		if restoreErr := restore(); restoreErr != nil {
+			return nil, fmt.Errorf("%w (rollback failed: %v)", err, restoreErr)
+		}
+		return nil, err
+	}
+
+	return restore, nil
+}
+
+// finishInstall enables a freshly swapped-in addon and records it in the
+// manifest along with its workshop revision
+func (m *Manager) finishInstall(id, source, archive string, gma *file.GMA) error {
+	// The symlink points at OutDir/<id>, so a reinstall keeps it valid
+	wasEnabled := m.localAddonInfo(id).Enabled
+	if !wasEnabled {
+		if err := m.EnableAddon(id); err != nil {
+			return err
+		}
+	}
+
+	entry := ManifestEntry{
+		ID:          id,
+		Title:       gma.Name,
+		Source:      source,
+		InstalledAt: time.Now(),
+		FileCount:   len(gma.Files),
+		Size:        gma.Size(),
+		Enabled:     true,
+		Archive:     archive,
+	}
+	if source == SourceWorkshop {
+		if workshopAddons, err := m.RefreshWorkshopAddonsInfo([]string{id}); err == nil && workshopAddons[id] != nil {
+			entry.TimeUpdated = workshopAddons[id].TimeUpdated
+		}
+	}
+	if err := m.manifest.Put(entry); err != nil {
+		if !wasEnabled {
+			os.Remove(filepath.Join(m.config.AddonDir, id))
+		}
+		return err
+	}
+	return nil
+}
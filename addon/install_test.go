@@ -0,0 +1,267 @@
+package addon
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// installTestGMA installs an archive with the given lua contents as a
+// workshop addon, answering the workshop lookup locally
+func installTestGMA(t *testing.T, m *Manager, id, body string) error {
+	t.Helper()
+	var batches []int
+	serveSteamAPI(t, &publishedFileDetailsURL, publishedFileDetailsHandler(t, &batches, nil))
+	return m.installDownloaded(id, writeTestGMA(t, body), SourceWorkshop)
+}
+
+func readInstalledLua(t *testing.T, m *Manager, id string) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(m.config.OutDir, id, "lua", "autorun", "test.lua"))
+	if err != nil {
+		t.Fatalf("installed addon %s: %v", id, err)
+	}
+	return string(data)
+}
+
+// writeTestZip packs the given files into a zip, the way addons are often
+// shared outside the workshop
+func writeTestZip(t *testing.T, files map[string][]byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "addon.zip")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	w := zip.NewWriter(f)
+	for name, data := range files {
+		fw, err := w.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := fw.Write(data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func readTestFile(t *testing.T, path string) []byte {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestInstallDownloaded(t *testing.T) {
+	m := newTestManager(t)
+
+	if err := installTestGMA(t, m, "111", "v1"); err != nil {
+		t.Fatalf("installDownloaded: %v", err)
+	}
+	if got := readInstalledLua(t, m, "111"); got != "v1" {
+		t.Errorf("installed contents = %q, want v1", got)
+	}
+	if addon := m.localAddonInfo("111"); !addon.Installed || !addon.Enabled {
+		t.Errorf("addon = %+v, want installed and enabled", addon)
+	}
+	if entry, ok := m.manifest.Get("111"); !ok || entry.Source != SourceWorkshop || entry.FileCount != 1 {
+		t.Errorf("manifest entry = %+v, %v", entry, ok)
+	}
+
+	// Reinstalling swaps the new version in and drops the backup
+	if err := installTestGMA(t, m, "111", "v2"); err != nil {
+		t.Fatalf("reinstall: %v", err)
+	}
+	if got := readInstalledLua(t, m, "111"); got != "v2" {
+		t.Errorf("reinstalled contents = %q, want v2", got)
+	}
+	if dirExists(m.backupDir("111")) {
+		t.Error("backup left behind after a successful install")
+	}
+}
+
+func TestInstallRestoresInterruptedSwap(t *testing.T) {
+	m := newTestManager(t)
+	if err := installTestGMA(t, m, "111", "v1"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Simulate a crash after the installed version was moved aside
+	if err := os.Rename(filepath.Join(m.config.OutDir, "111"), m.backupDir("111")); err != nil {
+		t.Fatal(err)
+	}
+
+	// Listing doesn't mistake the backup for an addon
+	report, err := m.Reconcile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Added) != 0 {
+		t.Errorf("Reconcile added %v", report.Added)
+	}
+
+	// The next install puts the backup back before anything else, so a
+	// failing install leaves the previous version in place
+	corrupt := filepath.Join(t.TempDir(), "corrupt.gma")
+	if err := os.WriteFile(corrupt, []byte("GMAD garbage"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.installDownloaded("111", corrupt, SourceWorkshop); err == nil {
+		t.Fatal("installing a corrupt archive succeeded")
+	}
+	if got := readInstalledLua(t, m, "111"); got != "v1" {
+		t.Errorf("contents after recovery = %q, want v1", got)
+	}
+	if dirExists(m.backupDir("111")) {
+		t.Error("backup left behind after recovery")
+	}
+}
+
+func TestInstallDropsBackupOfFinishedSwap(t *testing.T) {
+	m := newTestManager(t)
+	if err := installTestGMA(t, m, "111", "v2"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Simulate a crash after the new version was moved in but before the
+	// backup was removed
+	if err := os.MkdirAll(m.backupDir("111"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.recoverBackup("111"); err != nil {
+		t.Fatal(err)
+	}
+	if dirExists(m.backupDir("111")) {
+		t.Error("backup of a finished swap was kept")
+	}
+	if got := readInstalledLua(t, m, "111"); got != "v2" {
+		t.Errorf("contents = %q, want v2", got)
+	}
+}
+
+func TestInstallFromFile(t *testing.T) {
+	m := newTestManager(t)
+
+	id, err := m.InstallFromFile(writeTestGMA(t, "v1"), "111")
+	if err != nil {
+		t.Fatalf("InstallFromFile: %v", err)
+	}
+	if id != "111" {
+		t.Errorf("installed as %s, want 111", id)
+	}
+	if got := readInstalledLua(t, m, id); got != "v1" {
+		t.Errorf("installed contents = %q, want v1", got)
+	}
+	if entry, ok := m.manifest.Get(id); !ok || entry.Source != SourceFile {
+		t.Errorf("manifest entry = %+v, %v", entry, ok)
+	}
+
+	if _, err := m.InstallFromFile(filepath.Join(t.TempDir(), "addon.txt"), ""); err == nil {
+		t.Error("installing an unsupported file type succeeded")
+	}
+}
+
+func TestInstallFromZipKeepsArchive(t *testing.T) {
+	m := newTestManager(t)
+
+	gma := readTestFile(t, writeTestGMA(t, "v1"))
+	id, err := m.InstallFromFile(writeTestZip(t, map[string][]byte{"old/addon.gma": gma}), "111")
+	if err != nil {
+		t.Fatalf("InstallFromFile: %v", err)
+	}
+	kept := filepath.Join(m.keptArchiveDir(id), "addon.gma")
+	if entry, _ := m.manifest.Get(id); entry.Archive != kept {
+		t.Errorf("manifest archive = %q, want %q", entry.Archive, kept)
+	}
+
+	// A reinstall keeps only the new archive, even under another name
+	gma = readTestFile(t, writeTestGMA(t, "v2"))
+	if _, err := m.InstallFromFile(writeTestZip(t, map[string][]byte{"new/renamed.gma": gma}), id); err != nil {
+		t.Fatalf("reinstall: %v", err)
+	}
+	if got := readInstalledLua(t, m, id); got != "v2" {
+		t.Errorf("reinstalled contents = %q, want v2", got)
+	}
+	entries, err := os.ReadDir(m.keptArchiveDir(id))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Name() != "renamed.gma" {
+		t.Errorf("kept archives = %v, want only renamed.gma", entries)
+	}
+}
+
+func TestInstallFromZipUpperCase(t *testing.T) {
+	m := newTestManager(t)
+
+	gma := readTestFile(t, writeTestGMA(t, "v1"))
+	id, err := m.InstallFromFile(writeTestZip(t, map[string][]byte{"ADDON.GMA": gma}), "111")
+	if err != nil {
+		t.Fatalf("InstallFromFile: %v", err)
+	}
+	if got := readInstalledLua(t, m, id); got != "v1" {
+		t.Errorf("installed contents = %q, want v1", got)
+	}
+	report, err := m.VerifyAddon(id)
+	if err != nil {
+		t.Fatalf("VerifyAddon: %v", err)
+	}
+	if !report.OK() {
+		t.Errorf("verify report = %+v, want ok", report)
+	}
+}
+
+func TestInstallFromZipFailureKeepsPreviousArchive(t *testing.T) {
+	m := newTestManager(t)
+
+	gma := readTestFile(t, writeTestGMA(t, "v1"))
+	id, err := m.InstallFromFile(writeTestZip(t, map[string][]byte{"addon.gma": gma}), "111")
+	if err != nil {
+		t.Fatalf("InstallFromFile: %v", err)
+	}
+
+	// A broken archive with the same name must not replace the working one
+	broken := writeTestZip(t, map[string][]byte{"addon.gma": []byte("not a gma")})
+	if _, err := m.InstallFromFile(broken, id); err == nil {
+		t.Fatal("installing a broken archive succeeded")
+	}
+	kept := filepath.Join(m.keptArchiveDir(id), "addon.gma")
+	if got := readTestFile(t, kept); string(got) != string(gma) {
+		t.Error("kept archive was replaced by the failed install")
+	}
+	if entry, _ := m.manifest.Get(id); entry.Archive != kept {
+		t.Errorf("manifest archive = %q, want %q", entry.Archive, kept)
+	}
+	if got := readInstalledLua(t, m, id); got != "v1" {
+		t.Errorf("installed contents = %q, want v1", got)
+	}
+}
+
+func TestValidAddonID(t *testing.T) {
+	tests := map[string]bool{
+		"123456789":    true,
+		"local-abc123": true,
+		"":             false,
+		"0":            false,
+		".":            false,
+		"..":           false,
+		".111.bak":     false,
+		"a/b":          false,
+		`a\b`:          false,
+		"c:":           false,
+	}
+	for id, want := range tests {
+		if got := validAddonID(id); got != want {
+			t.Errorf("validAddonID(%q) = %v, want %v", id, got, want)
+		}
+	}
+}
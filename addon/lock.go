@@ -0,0 +1,142 @@
+package addon
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// lockFileName is the lock file in the data directory guarding changes to
+// the addons directory across processes
+const lockFileName = "lock"
+
+// DefaultLockTimeout is how long a change waits for another process to
+// release the addons directory
+const DefaultLockTimeout = 10 * time.Second
+
+// ErrLocked is returned when another process holds the addons directory
+var ErrLocked = errors.New("addons directory is in use by another process")
+
+// errLockHeld is returned by tryLock when the file is locked elsewhere
+var errLockHeld = errors.New("lock is held")
+
+// dirLock is an advisory lock on a file, shared by every goroutine of this
+// process. Goroutines are kept apart by finer grained locks, see lockAddon.
+type dirLock struct {
+	path string
+
+	mu   sync.Mutex
+	held int
+	f    *os.File
+}
+
+// acquire takes the lock, waiting up to timeout for another process to
+// release it. acquired runs when the file lock is newly taken, before any
+// other goroutine can share the hold.
+func (l *dirLock) acquire(timeout time.Duration, wait func(), acquired func() error) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.held > 0 {
+		l.held++
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(l.path), 0755); err != nil {
+		return fmt.Errorf("failed to create lock directory: %w", err)
+	}
+	f, err := os.OpenFile(l.path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open lock file: %w", err)
+	}
+
+	deadline := time.Now().Add(timeout)
+	for waited := false; ; waited = true {
+		err = tryLock(f)
+		if err == nil {
+			break
+		}
+		if !errors.Is(err, errLockHeld) {
+			f.Close()
+			return fmt.Errorf("failed to lock %s: %w", l.path, err)
+		}
+		if time.Now().After(deadline) {
+			f.Close()
+			return l.lockedError()
+		}
+		if !waited && wait != nil {
+			wait()
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	// Record who holds the lock for the error other processes report
+	if err := f.Truncate(0); err == nil {
+		f.WriteAt([]byte(strconv.Itoa(os.Getpid())), 0)
+	}
+
+	if acquired != nil {
+		if err := acquired(); err != nil {
+			unlock(f)
+			f.Close()
+			return err
+		}
+	}
+
+	l.f = f
+	l.held = 1
+	return nil
+}
+
+// release gives up one hold on the lock, unlocking the file with the last
+func (l *dirLock) release() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.held--
+	if l.held > 0 {
+		return
+	}
+	unlock(l.f)
+	l.f.Close()
+	l.f = nil
+}
+
+func (l *dirLock) lockedError() error {
+	data, err := os.ReadFile(l.path)
+	if err != nil {
+		return fmt.Errorf("%w (%s)", ErrLocked, l.path)
+	}
+	pid := strings.TrimSpace(string(data))
+	if pid == "" {
+		return fmt.Errorf("%w (%s)", ErrLocked, l.path)
+	}
+	return fmt.Errorf("%w (pid %s, %s)", ErrLocked, pid, l.path)
+}
+
+// SetLockTimeout sets how long changes wait for another process to release
+// the addons directory. Zero fails immediately.
+func (m *Manager) SetLockTimeout(timeout time.Duration) {
+	m.lockTimeout = timeout
+}
+
+// lock takes the cross-process lock on the addons directory. Every method
+// changing addons or the manifest holds it. The workshop cache is not
+// covered: its entries are written to a temporary file and renamed into
+// place, so concurrent writers can't leave a torn entry behind.
+func (m *Manager) lock() (func(), error) {
+	wait := func() {
+		m.log("Waiting for another gmod-addon-manager process to finish...")
+	}
+	// Another process may have changed the manifest since it was loaded
+	err := m.dirLock.acquire(m.lockTimeout, wait, m.manifest.Reload)
+	if err != nil {
+		return nil, err
+	}
+	return m.dirLock.release, nil
+}
@@ -0,0 +1,111 @@
+package addon
+
+import (
+	"errors"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newSecondManager returns another manager on the same addons directory,
+// standing in for a second process
+func newSecondManager(t *testing.T, m *Manager) *Manager {
+	t.Helper()
+	other, err := NewManager(m.config)
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+	other.SetVerbose(false)
+	other.SetLockTimeout(0)
+	return other
+}
+
+func TestLockReentrant(t *testing.T) {
+	m := newTestManager(t)
+	m.SetLockTimeout(0)
+	other := newSecondManager(t, m)
+
+	unlockOuter, err := m.lock()
+	if err != nil {
+		t.Fatalf("lock: %v", err)
+	}
+	// A nested hold doesn't wait for the outer one
+	unlockInner, err := m.lock()
+	if err != nil {
+		t.Fatalf("nested lock: %v", err)
+	}
+
+	_, err = other.lock()
+	if !errors.Is(err, ErrLocked) || !strings.Contains(err.Error(), strconv.Itoa(os.Getpid())) {
+		t.Errorf("lock while held = %v, want ErrLocked naming this process", err)
+	}
+
+	// The file stays locked until the outer hold is released
+	unlockInner()
+	if _, err := other.lock(); !errors.Is(err, ErrLocked) {
+		t.Errorf("lock after the inner release = %v, want ErrLocked", err)
+	}
+	unlockOuter()
+
+	unlock, err := other.lock()
+	if err != nil {
+		t.Fatalf("lock after release: %v", err)
+	}
+	unlock()
+}
+
+func TestLockContention(t *testing.T) {
+	m := newTestManager(t)
+	other := newSecondManager(t, m)
+
+	unlockOther, err := other.lock()
+	if err != nil {
+		t.Fatalf("lock: %v", err)
+	}
+
+	// The holder records an install the waiting manager hasn't loaded
+	if err := other.manifest.Put(ManifestEntry{ID: "111", Source: SourceWorkshop}); err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		time.Sleep(300 * time.Millisecond)
+		unlockOther()
+	}()
+
+	m.SetLockTimeout(5 * time.Second)
+	start := time.Now()
+	unlock, err := m.lock()
+	if err != nil {
+		t.Fatalf("lock: %v", err)
+	}
+	defer unlock()
+
+	if waited := time.Since(start); waited < 200*time.Millisecond {
+		t.Errorf("lock returned after %v while the directory was held", waited)
+	}
+	if _, ok := m.manifest.Get("111"); !ok {
+		t.Error("the manifest wasn't reloaded after waiting for the lock")
+	}
+}
+
+func TestLockTimeout(t *testing.T) {
+	m := newTestManager(t)
+	other := newSecondManager(t, m)
+
+	unlock, err := other.lock()
+	if err != nil {
+		t.Fatalf("lock: %v", err)
+	}
+	defer unlock()
+
+	m.SetLockTimeout(200 * time.Millisecond)
+	start := time.Now()
+	if _, err := m.lock(); !errors.Is(err, ErrLocked) {
+		t.Errorf("lock = %v, want ErrLocked", err)
+	}
+	if waited := time.Since(start); waited < 200*time.Millisecond {
+		t.Errorf("gave up after %v, want the full timeout", waited)
+	}
+}
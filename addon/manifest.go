@@ -0,0 +1,290 @@
+package addon
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// manifestFileName is the file in the data directory recording installs
+const manifestFileName = "manifest.json"
+
+// Sources recorded in the manifest
+const (
+	SourceWorkshop = "workshop"
+	SourceFile     = "file"    // installed from a local archive
+	SourceUnknown  = "unknown" // found on disk by Reconcile
+)
+
+// errCorruptManifest is returned by LoadManifest, along with an empty
+// manifest, when the file exists but can't be parsed
+var errCorruptManifest = errors.New("manifest is corrupt")
+
+// ManifestEntry records what the manager knows about an installed addon
+type ManifestEntry struct {
+	ID          string    `json:"id"`
+	Title       string    `json:"title,omitempty"`
+	Source      string    `json:"source"`
+	InstalledAt time.Time `json:"installed_at"`
+	TimeUpdated int64     `json:"time_updated"` // workshop time_updated of the installed copy
+	FileCount   int       `json:"file_count"`
+	Size        int64     `json:"size"`
+	Enabled     bool      `json:"enabled"`
+	Archive     string    `json:"archive,omitempty"` // downloaded file the addon was extracted from
+}
+
+// Manifest is the persistent record of installed addons
+type Manifest struct {
+	path   string
+	mu     sync.Mutex
+	Addons map[string]*ManifestEntry `json:"addons"`
+}
+
+// LoadManifest reads the manifest at path, returning an empty one if the
+// file does not exist yet. A file that can't be parsed yields an empty
+// manifest and an error wrapping errCorruptManifest, so it can be rebuilt.
+func LoadManifest(path string) (*Manifest, error) {
+	manifest := &Manifest{
+		path:   path,
+		Addons: make(map[string]*ManifestEntry),
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return manifest, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to read manifest: %w", err)
+	}
+
+	if err := json.Unmarshal(data, manifest); err != nil {
+		manifest.Addons = make(map[string]*ManifestEntry)
+		return manifest, fmt.Errorf("%w: %v", errCorruptManifest, err)
+	}
+	if manifest.Addons == nil {
+		manifest.Addons = make(map[string]*ManifestEntry)
+	}
+
+	return manifest, nil
+}
+
+// Reload re-reads the manifest from disk, picking up changes made by other
+// processes. A file that can't be parsed leaves the entries in memory as they
+// are, to be written back by the next save.
+func (m *Manifest) Reload() error {
+	loaded, err := LoadManifest(m.path)
+	if errors.Is(err, errCorruptManifest) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.Addons = loaded.Addons
+	return nil
+}
+
+// Get returns a copy of the entry for an addon
+func (m *Manifest) Get(id string) (ManifestEntry, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry, ok := m.Addons[id]
+	if !ok {
+		return ManifestEntry{}, false
+	}
+	return *entry, true
+}
+
+// Put stores an entry and saves the manifest
+func (m *Manifest) Put(entry ManifestEntry) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.Addons[entry.ID] = &entry
+	return m.save()
+}
+
+// Delete removes an entry and saves the manifest
+func (m *Manifest) Delete(id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.Addons[id]; !ok {
+		return nil
+	}
+	delete(m.Addons, id)
+	return m.save()
+}
+
+// Update applies fn to an existing entry and saves the manifest. It
+// reports whether the entry existed.
+func (m *Manifest) Update(id string, fn func(entry *ManifestEntry)) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry, ok := m.Addons[id]
+	if !ok {
+		return false, nil
+	}
+	fn(entry)
+	return true, m.save()
+}
+
+// Entries returns a copy of every entry
+func (m *Manifest) Entries() map[string]ManifestEntry {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entries := make(map[string]ManifestEntry, len(m.Addons))
+	for id, entry := range m.Addons {
+		entries[id] = *entry
+	}
+	return entries
+}
+
+// Replace swaps every entry at once and saves the manifest
+func (m *Manifest) Replace(entries map[string]ManifestEntry) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.Addons = make(map[string]*ManifestEntry, len(entries))
+	for id, entry := range entries {
+		m.Addons[id] = &entry
+	}
+	return m.save()
+}
+
+func (m *Manifest) save() error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal manifest: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(m.path), 0755); err != nil {
+		return fmt.Errorf("failed to create manifest directory: %w", err)
+	}
+	// Replace the file in one step so a crash can't leave it truncated
+	if err := writeFileAtomic(m.path, bytes.NewReader(data)); err != nil {
+		return fmt.Errorf("failed to write manifest: %w", err)
+	}
+	return nil
+}
+
+// ReconcileReport lists the manifest changes made by Reconcile
+type ReconcileReport struct {
+	Added   []string // installed on disk but missing from the manifest
+	Removed []string // in the manifest but no longer on disk
+	Updated []string // enabled state differed from the addons directory
+}
+
+// Reconcile repairs the manifest from the addons on disk
+func (m *Manager) Reconcile() (*ReconcileReport, error) {
+	unlock, err := m.lock()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	dirEntries, err := os.ReadDir(m.config.OutDir)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read out directory: %w", err)
+	}
+
+	report := &ReconcileReport{}
+	entries := m.manifest.Entries()
+	onDisk := make(map[string]bool, len(dirEntries))
+
+	for _, dirEntry := range dirEntries {
+		if !isAddonDir(dirEntry) {
+			continue
+		}
+		id := dirEntry.Name()
+		onDisk[id] = true
+
+		entry, ok := entries[id]
+		if !ok {
+			entry, err = m.scanAddon(id)
+			if err != nil {
+				return nil, err
+			}
+			report.Added = append(report.Added, id)
+		}
+
+		enabled := m.localAddonInfo(id).Enabled
+		if ok && entry.Enabled != enabled {
+			report.Updated = append(report.Updated, id)
+		}
+		entry.Enabled = enabled
+		entries[id] = entry
+	}
+
+	for id := range entries {
+		if !onDisk[id] {
+			delete(entries, id)
+			report.Removed = append(report.Removed, id)
+		}
+	}
+
+	if err := m.manifest.Replace(entries); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// scanAddon builds a manifest entry for an addon folder the manager has
+// no record of
+func (m *Manager) scanAddon(id string) (ManifestEntry, error) {
+	addonDir := filepath.Join(m.config.OutDir, id)
+	info, err := os.Stat(addonDir)
+	if err != nil {
+		return ManifestEntry{}, fmt.Errorf("failed to stat addon directory: %w", err)
+	}
+
+	entry := ManifestEntry{
+		ID:          id,
+		Source:      SourceUnknown,
+		InstalledAt: info.ModTime(),
+	}
+	err = filepath.WalkDir(addonDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		fileInfo, err := d.Info()
+		if err != nil {
+			return err
+		}
+		entry.FileCount++
+		entry.Size += fileInfo.Size()
+		return nil
+	})
+	if err != nil {
+		return ManifestEntry{}, fmt.Errorf("failed to scan addon directory: %w", err)
+	}
+
+	return entry, nil
+}
+
+// recordEnabled updates the enabled state of an addon in the manifest,
+// adding an entry for it if the manager had no record yet
+func (m *Manager) recordEnabled(id string, enabled bool) error {
+	found, err := m.manifest.Update(id, func(entry *ManifestEntry) {
+		entry.Enabled = enabled
+	})
+	if err != nil || found {
+		return err
+	}
+
+	entry, err := m.scanAddon(id)
+	if err != nil {
+		return err
+	}
+	entry.Enabled = enabled
+	return m.manifest.Put(entry)
+}
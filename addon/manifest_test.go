@@ -0,0 +1,75 @@
+package addon
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestManifestSave(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "0", manifestFileName)
+	manifest, err := LoadManifest(path)
+	if err != nil || len(manifest.Entries()) != 0 {
+		t.Fatalf("LoadManifest of a missing file = %v, %v, want an empty manifest", manifest, err)
+	}
+
+	if err := manifest.Put(ManifestEntry{ID: "111", Source: SourceWorkshop, Enabled: true}); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if err := manifest.Put(ManifestEntry{ID: "222", Source: SourceUnknown}); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if err := manifest.Delete("222"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+
+	loaded, err := LoadManifest(path)
+	if err != nil {
+		t.Fatalf("LoadManifest: %v", err)
+	}
+	entries := loaded.Entries()
+	if len(entries) != 1 || entries["111"].Source != SourceWorkshop || !entries["111"].Enabled {
+		t.Errorf("loaded entries = %+v, want only 111", entries)
+	}
+
+	// Saving leaves no temporary files behind
+	dirEntries, err := os.ReadDir(filepath.Dir(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dirEntries) != 1 {
+		t.Errorf("manifest directory has %d files, want only the manifest", len(dirEntries))
+	}
+}
+
+func TestNewManagerRebuildsCorruptManifest(t *testing.T) {
+	m := newTestManager(t)
+	if err := os.MkdirAll(filepath.Join(m.config.OutDir, "111", "lua"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(m.config.OutDir, "111", "lua", "init.lua"), []byte("print('hi')"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	manifestPath := filepath.Join(m.dataDir(), manifestFileName)
+	if err := os.WriteFile(manifestPath, []byte(`{"addons": {"111": `), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := LoadManifest(manifestPath); err == nil {
+		t.Error("LoadManifest accepted a truncated manifest")
+	}
+
+	rebuilt, err := NewManager(m.config)
+	if err != nil {
+		t.Fatalf("NewManager with a corrupt manifest: %v", err)
+	}
+	entry, ok := rebuilt.manifest.Get("111")
+	if !ok || entry.Source != SourceUnknown || entry.FileCount != 1 {
+		t.Errorf("rebuilt entry = %+v, %v, want 111 scanned from disk", entry, ok)
+	}
+
+	// The rebuilt manifest replaced the corrupt file
+	if _, err := LoadManifest(manifestPath); err != nil {
+		t.Errorf("LoadManifest after the rebuild: %v", err)
+	}
+}
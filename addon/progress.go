@@ -0,0 +1,61 @@
+package addon
+
+import "gmod-addon-manager/file"
+
+// InstallStage is a step of installing an addon
+type InstallStage int
+
+const (
+	StageDownload InstallStage = iota
+	StageDecompress
+	StageExtract
+	StageEnable
+)
+
+// InstallStages lists every stage in the order an install goes through them
+var InstallStages = []InstallStage{StageDownload, StageDecompress, StageExtract, StageEnable}
+
+func (s InstallStage) String() string {
+	switch s {
+	case StageDownload:
+		return "Download"
+	case StageDecompress:
+		return "Decompress"
+	case StageExtract:
+		return "Extract"
+	case StageEnable:
+		return "Enable"
+	default:
+		return "Unknown"
+	}
+}
+
+// ProgressEvent reports how far an install has got. Done and Total are
+// byte counts when the stage can measure them, Total is -1 when unknown.
+type ProgressEvent struct {
+	ID      string // empty for events that aren't about one addon, like a steam login
+	Stage   InstallStage
+	Done    int64
+	Total   int64
+	Message string
+}
+
+// SetProgressHandler routes install progress to fn. Without a handler,
+// download progress is printed in verbose mode.
+func (m *Manager) SetProgressHandler(fn func(ProgressEvent)) {
+	m.progressHandler = fn
+}
+
+func (m *Manager) progress(event ProgressEvent) {
+	m.Jobs().observe(event)
+	if m.progressHandler != nil {
+		m.progressHandler(event)
+	}
+}
+
+// stageProgress returns a file.ProgressFunc reporting bytes for a stage
+func (m *Manager) stageProgress(id string, stage InstallStage) file.ProgressFunc {
+	return func(done, total int64) {
+		m.progress(ProgressEvent{ID: id, Stage: stage, Done: done, Total: total})
+	}
+}
@@ -0,0 +1,103 @@
+package addon
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestInstallProgress(t *testing.T) {
+	m := newTestManager(t)
+	m.SetInstallDependencies(false)
+	m.SetDownloaders(&fakeDownloader{name: "fake", files: map[string]string{"111": writeTestGMA(t, "print('hello')")}})
+	var batches []int
+	serveSteamAPI(t, &publishedFileDetailsURL, publishedFileDetailsHandler(t, &batches, nil))
+
+	var events []ProgressEvent
+	m.SetProgressHandler(func(event ProgressEvent) {
+		events = append(events, event)
+	})
+
+	for _, result := range m.GetAddons([]string{"111"}) {
+		if result.Err != nil {
+			t.Fatalf("GetAddons: %v", result.Err)
+		}
+	}
+
+	// Every stage is reported in order, and byte counts never go back
+	var stages []InstallStage
+	var done int64
+	for _, event := range events {
+		if event.ID != "111" {
+			t.Errorf("event for %q, want 111", event.ID)
+		}
+		if len(stages) == 0 || stages[len(stages)-1] != event.Stage {
+			stages = append(stages, event.Stage)
+			done = 0
+		}
+		if event.Done < done {
+			t.Errorf("%s progress went back from %d to %d", event.Stage, done, event.Done)
+		}
+		done = event.Done
+		if event.Stage == StageExtract && event.Total >= 0 && event.Done > event.Total {
+			t.Errorf("extracted %d of %d bytes", event.Done, event.Total)
+		}
+	}
+	if !slices.Equal(stages, InstallStages) {
+		t.Errorf("stages = %v, want %v", stages, InstallStages)
+	}
+
+	// Extraction ends with every byte accounted for
+	var last ProgressEvent
+	for _, event := range events {
+		if event.Stage == StageExtract {
+			last = event
+		}
+	}
+	if last.Total <= 0 || last.Done != last.Total {
+		t.Errorf("last extract event = %+v, want a complete byte count", last)
+	}
+}
+
+func TestSteamCmdEventProgress(t *testing.T) {
+	tests := []struct {
+		name  string
+		event SteamCmdEvent
+		done  int64
+		total int64
+	}{
+		{
+			name:  "downloading",
+			event: SteamCmdEvent{Type: SteamCmdDownloadStarted, ID: "111"},
+			total: -1,
+		},
+		{
+			name:  "downloaded",
+			event: SteamCmdEvent{Type: SteamCmdDownloadSuccess, ID: "111", Bytes: 4096},
+			done:  4096,
+			total: 4096,
+		},
+		{
+			name:  "failed",
+			event: SteamCmdEvent{Type: SteamCmdDownloadFailed, ID: "111", Reason: "Timeout"},
+			total: -1,
+		},
+	}
+
+	m := newTestManager(t)
+	for _, tt := range tests {
+		var got []ProgressEvent
+		m.SetProgressHandler(func(event ProgressEvent) {
+			got = append(got, event)
+		})
+		m.steamCmdEvent(tt.event)
+
+		if len(got) != 1 {
+			t.Errorf("%s: got %d events, want 1", tt.name, len(got))
+			continue
+		}
+		event := got[0]
+		if event.ID != tt.event.ID || event.Stage != StageDownload || event.Done != tt.done || event.Total != tt.total || event.Message != tt.event.String() {
+			t.Errorf("%s: event = %+v", tt.name, event)
+		}
+	}
+}
@@ -0,0 +1,310 @@
+package addon
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// JobKind is what a queued job does with its addon
+type JobKind int
+
+const (
+	JobInstall JobKind = iota // install the addon and its missing dependencies
+	JobUpdate                 // reinstall the addon if the workshop copy is newer
+)
+
+func (k JobKind) String() string {
+	switch k {
+	case JobInstall:
+		return "install"
+	case JobUpdate:
+		return "update"
+	default:
+		return "unknown"
+	}
+}
+
+// JobState is where a job is in the queue
+type JobState int
+
+const (
+	JobQueued JobState = iota
+	JobRunning
+	JobDone
+	JobFailed
+	JobCanceled
+)
+
+func (s JobState) String() string {
+	switch s {
+	case JobQueued:
+		return "queued"
+	case JobRunning:
+		return "running"
+	case JobDone:
+		return "done"
+	case JobFailed:
+		return "failed"
+	case JobCanceled:
+		return "canceled"
+	default:
+		return "unknown"
+	}
+}
+
+// Active reports whether the job is still waiting or running
+func (s JobState) Active() bool {
+	return s == JobQueued || s == JobRunning
+}
+
+// Job is a snapshot of a queued install or update
+type Job struct {
+	ID      string
+	Kind    JobKind
+	State   JobState
+	Stage   InstallStage // last stage reported while running
+	Done    int64        // bytes processed in the current stage
+	Total   int64        // -1 when unknown
+	Err     error
+	Updated bool // for JobUpdate, whether a newer version was installed
+
+	QueuedAt   time.Time
+	StartedAt  time.Time
+	FinishedAt time.Time
+}
+
+type job struct {
+	Job
+	ctx    context.Context
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// JobHandle refers to a job added to a JobQueue
+type JobHandle struct {
+	queue *JobQueue
+	job   *job
+}
+
+// Wait blocks until the job has finished and returns its final state
+func (h *JobHandle) Wait() Job {
+	<-h.job.done
+	return h.queue.snapshot(h.job)
+}
+
+// Cancel stops the job, or drops it if it hasn't started yet
+func (h *JobHandle) Cancel() {
+	h.job.cancel()
+}
+
+// JobQueue runs installs and updates on a fixed number of workers. While
+// one job extracts, another can already be downloading.
+type JobQueue struct {
+	manager *Manager
+	workers int
+
+	mu      sync.Mutex
+	jobs    []*job
+	pending []*job
+	running int // workers currently alive
+}
+
+// NewJobQueue creates a queue running up to workers jobs at a time
+func NewJobQueue(manager *Manager, workers int) *JobQueue {
+	return &JobQueue{
+		manager: manager,
+		workers: max(workers, 1),
+	}
+}
+
+// Jobs returns the manager's job queue, sized from the workers setting
+func (m *Manager) Jobs() *JobQueue {
+	m.jobsOnce.Do(func() {
+		m.jobs = NewJobQueue(m, m.config.Workers)
+	})
+	return m.jobs
+}
+
+// Add queues a job for an addon. If the addon already has an active job,
+// its handle is returned instead of queueing a second one.
+func (q *JobQueue) Add(ctx context.Context, id string, kind JobKind) *JobHandle {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for _, j := range q.jobs {
+		if j.ID == id && j.State.Active() {
+			return &JobHandle{queue: q, job: j}
+		}
+	}
+
+	jobCtx, cancel := context.WithCancel(ctx)
+	j := &job{
+		Job: Job{
+			ID:       id,
+			Kind:     kind,
+			State:    JobQueued,
+			Total:    -1,
+			QueuedAt: time.Now(),
+		},
+		ctx:    jobCtx,
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+	q.jobs = append(q.jobs, j)
+	q.pending = append(q.pending, j)
+
+	if q.running < q.workers {
+		q.running++
+		go q.work()
+	}
+
+	return &JobHandle{queue: q, job: j}
+}
+
+// Cancel cancels the active job of an addon, reporting whether there was one
+func (q *JobQueue) Cancel(id string) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for _, j := range q.jobs {
+		if j.ID == id && j.State.Active() {
+			j.cancel()
+			return true
+		}
+	}
+	return false
+}
+
+// Jobs returns a snapshot of every job, in the order they were added
+func (q *JobQueue) Jobs() []Job {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	jobs := make([]Job, 0, len(q.jobs))
+	for _, j := range q.jobs {
+		jobs = append(jobs, j.Job)
+	}
+	return jobs
+}
+
+// Active reports whether any job is waiting or running
+func (q *JobQueue) Active() bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for _, j := range q.jobs {
+		if j.State.Active() {
+			return true
+		}
+	}
+	return false
+}
+
+// ClearFinished forgets every job that is no longer active
+func (q *JobQueue) ClearFinished() {
+	q.forget(func(*job) bool { return true })
+}
+
+// Forget drops the finished jobs of an addon once their outcome has been
+// reported, so a long session doesn't keep every job it ever ran
+func (q *JobQueue) Forget(id string) {
+	q.forget(func(j *job) bool { return j.ID == id })
+}
+
+// forget drops the finished jobs matching fn
+func (q *JobQueue) forget(fn func(*job) bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	kept := q.jobs[:0]
+	for _, j := range q.jobs {
+		if j.State.Active() || !fn(j) {
+			kept = append(kept, j)
+		}
+	}
+	clear(q.jobs[len(kept):])
+	q.jobs = kept
+}
+
+func (q *JobQueue) snapshot(j *job) Job {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return j.Job
+}
+
+// work runs pending jobs until there are none left
+func (q *JobQueue) work() {
+	for {
+		q.mu.Lock()
+		if len(q.pending) == 0 {
+			q.running--
+			q.mu.Unlock()
+			return
+		}
+		j := q.pending[0]
+		q.pending = q.pending[1:]
+
+		if j.ctx.Err() != nil {
+			q.finish(j, false, j.ctx.Err())
+			q.mu.Unlock()
+			continue
+		}
+		j.State = JobRunning
+		j.StartedAt = time.Now()
+		q.mu.Unlock()
+
+		updated, err := q.run(j)
+
+		q.mu.Lock()
+		q.finish(j, updated, err)
+		q.mu.Unlock()
+	}
+}
+
+func (q *JobQueue) run(j *job) (bool, error) {
+	switch j.Kind {
+	case JobInstall:
+		return false, q.manager.getAddon(j.ctx, j.ID)
+	case JobUpdate:
+		return q.manager.updateAddon(j.ctx, j.ID)
+	default:
+		return false, fmt.Errorf("unknown job kind %d", j.Kind)
+	}
+}
+
+// finish records the outcome of a job. The caller holds q.mu.
+func (q *JobQueue) finish(j *job, updated bool, err error) {
+	j.Updated = updated
+	j.Err = err
+	j.FinishedAt = time.Now()
+	switch {
+	case err != nil && errors.Is(err, context.Canceled):
+		j.State = JobCanceled
+	case err != nil:
+		j.State = JobFailed
+	default:
+		j.State = JobDone
+	}
+	j.cancel()
+	close(j.done)
+}
+
+// observe tracks the stage of running jobs from install progress
+func (q *JobQueue) observe(event ProgressEvent) {
+	if event.ID == "" {
+		return
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for _, j := range q.jobs {
+		if j.ID == event.ID && j.State == JobRunning {
+			j.Stage = event.Stage
+			j.Done, j.Total = event.Done, event.Total
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package addon
+
+import (
+	"context"
+	"testing"
+)
+
+// blockingDownloader holds every download until its context is canceled
+type blockingDownloader struct {
+	started chan string
+}
+
+func (d *blockingDownloader) Name() string {
+	return "blocking"
+}
+
+func (d *blockingDownloader) Download(ctx context.Context, id string) (string, error) {
+	d.started <- id
+	<-ctx.Done()
+	return "", ctx.Err()
+}
+
+func newTestQueue(t *testing.T, downloaders ...Downloader) *JobQueue {
+	t.Helper()
+	m := newTestManager(t)
+	m.SetInstallDependencies(false)
+	m.SetDownloaders(downloaders...)
+	return NewJobQueue(m, 1)
+}
+
+func TestJobQueueFailedJob(t *testing.T) {
+	q := newTestQueue(t, &fakeDownloader{name: "empty"})
+
+	job := q.Add(context.Background(), "111", JobInstall).Wait()
+	if job.State != JobFailed || job.Err == nil {
+		t.Errorf("job = %+v, want failed", job)
+	}
+	if q.Active() {
+		t.Error("queue is active after its only job finished")
+	}
+}
+
+func TestJobQueueDeduplicatesActiveJobs(t *testing.T) {
+	d := &blockingDownloader{started: make(chan string, 1)}
+	q := newTestQueue(t, d)
+
+	first := q.Add(context.Background(), "111", JobInstall)
+	<-d.started
+	second := q.Add(context.Background(), "111", JobInstall)
+	if first.job != second.job {
+		t.Error("a second job was queued for an addon with an active one")
+	}
+	if jobs := q.Jobs(); len(jobs) != 1 || jobs[0].State != JobRunning {
+		t.Errorf("jobs = %+v, want one running", jobs)
+	}
+
+	if !q.Cancel("111") {
+		t.Error("Cancel found no active job")
+	}
+	if job := first.Wait(); job.State != JobCanceled {
+		t.Errorf("state = %s, want canceled", job.State)
+	}
+}
+
+func TestJobQueueCancelQueued(t *testing.T) {
+	d := &blockingDownloader{started: make(chan string, 2)}
+	q := newTestQueue(t, d)
+
+	running := q.Add(context.Background(), "111", JobInstall)
+	<-d.started
+	queued := q.Add(context.Background(), "222", JobInstall)
+
+	// The queued job is dropped without ever reaching the downloader
+	queued.Cancel()
+	running.Cancel()
+	if job := queued.Wait(); job.State != JobCanceled || !job.StartedAt.IsZero() {
+		t.Errorf("queued job = %+v, want canceled before starting", job)
+	}
+	running.Wait()
+	if len(d.started) != 0 {
+		t.Errorf("canceled job %s was started", <-d.started)
+	}
+}
+
+func TestJobQueueForget(t *testing.T) {
+	d := &blockingDownloader{started: make(chan string, 2)}
+	q := newTestQueue(t, d)
+
+	done := q.Add(context.Background(), "111", JobInstall)
+	<-d.started
+	done.Cancel()
+	done.Wait()
+
+	active := q.Add(context.Background(), "222", JobInstall)
+	<-d.started
+	defer active.Wait()
+	defer active.Cancel()
+
+	// Active jobs are kept, finished ones go once reported
+	q.Forget("222")
+	q.Forget("111")
+	jobs := q.Jobs()
+	if len(jobs) != 1 || jobs[0].ID != "222" {
+		t.Errorf("jobs after Forget = %+v, want only the active 222", jobs)
+	}
+}
@@ -0,0 +1,93 @@
+package addon
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"gmod-addon-manager/steam"
+)
+
+// SourceSteam marks addons copied from the Steam client's workshop downloads
+const SourceSteam = "steam"
+
+// SteamItem is a workshop subscription of the Steam client and what
+// ImportSteam will do with it
+type SteamItem struct {
+	ID          string
+	TimeUpdated int64
+	Path        string // the Steam client's .gma or _legacy.bin
+	Skip        string // reason the item won't be imported, empty if it will be
+}
+
+// DefaultSteamappsDir guesses the steamapps directory from GModDir, which
+// normally is <library>/steamapps/common/GarrysMod
+func (m *Manager) DefaultSteamappsDir() string {
+	common := filepath.Dir(filepath.Clean(m.config.GModDir))
+	if !strings.EqualFold(filepath.Base(common), "common") {
+		return ""
+	}
+	return filepath.Dir(common)
+}
+
+// PlanSteamImport lists the Garry's Mod subscriptions of the Steam client
+// using steamappsDir. Nothing is changed; pass the result to ImportSteam.
+func (m *Manager) PlanSteamImport(steamappsDir string) ([]SteamItem, error) {
+	workshopItems, err := steam.ReadAppWorkshop(steamappsDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read steam workshop manifest: %w", err)
+	}
+
+	contentDir := steam.WorkshopContentDir(steamappsDir)
+	items := make([]SteamItem, 0, len(workshopItems))
+	for _, workshopItem := range workshopItems {
+		item := SteamItem{ID: workshopItem.ID, TimeUpdated: workshopItem.TimeUpdated}
+		switch {
+		case !workshopItem.Subscribed:
+			item.Skip = "no longer subscribed"
+		case m.localAddonInfo(item.ID).Installed:
+			item.Skip = "already installed"
+		default:
+			path, err := firstDownloadedFile(contentDir, item.ID)
+			if err != nil || !isArchive(path) {
+				item.Skip = "not downloaded by Steam, use get instead"
+			}
+			item.Path = path
+		}
+		items = append(items, item)
+	}
+
+	return items, nil
+}
+
+// ImportSteam installs the items planned by PlanSteamImport from the Steam
+// client's copies, skipping items with a Skip reason. It returns an error
+// for every item that failed.
+func (m *Manager) ImportSteam(items []SteamItem) (map[string]error, error) {
+	unlock, err := m.lock()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	failed := make(map[string]error)
+	for _, item := range items {
+		if item.Skip != "" {
+			continue
+		}
+
+		if err := m.installDownloaded(item.ID, item.Path, SourceSteam); err != nil {
+			failed[item.ID] = err
+			continue
+		}
+
+		// The revision Steam downloaded, so updates are detected against it
+		if _, err := m.manifest.Update(item.ID, func(entry *ManifestEntry) {
+			entry.TimeUpdated = item.TimeUpdated
+		}); err != nil {
+			failed[item.ID] = err
+		}
+	}
+
+	return failed, nil
+}
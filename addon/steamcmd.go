@@ -0,0 +1,107 @@
+package addon
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gmod-addon-manager/file"
+)
+
+// SteamCmdEventType identifies what a line of steamcmd output reported
+type SteamCmdEventType int
+
+const (
+	SteamCmdLogin           SteamCmdEventType = iota // logging in to Steam
+	SteamCmdLoginFailed                              // login was refused or timed out
+	SteamCmdDownloadStarted                          // an item started downloading
+	SteamCmdDownloadSuccess                          // an item was downloaded to Path
+	SteamCmdDownloadFailed                           // an item failed with Reason
+)
+
+// SteamCmdEvent is a progress or result line parsed from steamcmd output
+type SteamCmdEvent struct {
+	Type   SteamCmdEventType
+	ID     string // workshop item, empty for login events
+	Path   string // download directory on success
+	Bytes  int64  // downloaded size on success
+	Reason string // failure reason, e.g. "Timeout"
+}
+
+// String renders the event as a line of progress output
+func (e SteamCmdEvent) String() string {
+	switch e.Type {
+	case SteamCmdLogin:
+		return "Logging in to Steam..."
+	case SteamCmdLoginFailed:
+		return fmt.Sprintf("Steam login failed: %s", e.Reason)
+	case SteamCmdDownloadStarted:
+		return fmt.Sprintf("Downloading addon %s...", e.ID)
+	case SteamCmdDownloadSuccess:
+		return fmt.Sprintf("Downloaded addon %s (%s).", e.ID, file.FormatSize(e.Bytes))
+	case SteamCmdDownloadFailed:
+		return fmt.Sprintf("Download of addon %s failed: %s", e.ID, e.Reason)
+	default:
+		return ""
+	}
+}
+
+var (
+	steamCmdLoginRe       = regexp.MustCompile(`^Logging in user '([^']*)'`)
+	steamCmdLoginFailRe   = regexp.MustCompile(`^FAILED (?:login with result code )?\(?([^)]*)\)?`)
+	steamCmdStartedRe     = regexp.MustCompile(`^Downloading item (\d+)`)
+	steamCmdSuccessRe     = regexp.MustCompile(`^Success\. Downloaded item (\d+) to "([^"]*)" \((\d+) bytes\)`)
+	steamCmdItemFailureRe = regexp.MustCompile(`^ERROR! Download item (\d+) failed \(([^)]*)\)`)
+)
+
+// ParseSteamCmdLine parses a line of steamcmd output. It reports false for
+// lines that don't carry login or download progress.
+func ParseSteamCmdLine(line string) (SteamCmdEvent, bool) {
+	line = strings.TrimSpace(line)
+
+	if match := steamCmdSuccessRe.FindStringSubmatch(line); match != nil {
+		size, _ := strconv.ParseInt(match[3], 10, 64)
+		return SteamCmdEvent{Type: SteamCmdDownloadSuccess, ID: match[1], Path: match[2], Bytes: size}, true
+	}
+	if match := steamCmdItemFailureRe.FindStringSubmatch(line); match != nil {
+		return SteamCmdEvent{Type: SteamCmdDownloadFailed, ID: match[1], Reason: match[2]}, true
+	}
+	if match := steamCmdStartedRe.FindStringSubmatch(line); match != nil {
+		return SteamCmdEvent{Type: SteamCmdDownloadStarted, ID: match[1]}, true
+	}
+	if steamCmdLoginRe.MatchString(line) {
+		// steamcmd prints the result on the same line, e.g. "...FAILED (No Connection)"
+		if _, result, ok := strings.Cut(line, "..."); ok && strings.HasPrefix(result, "FAILED") {
+			return steamCmdLoginFailure(result), true
+		}
+		return SteamCmdEvent{Type: SteamCmdLogin}, true
+	}
+	if strings.HasPrefix(line, "FAILED") {
+		return steamCmdLoginFailure(line), true
+	}
+	return SteamCmdEvent{}, false
+}
+
+func steamCmdLoginFailure(line string) SteamCmdEvent {
+	reason := "unknown error"
+	if match := steamCmdLoginFailRe.FindStringSubmatch(line); match != nil && match[1] != "" {
+		reason = match[1]
+	}
+	return SteamCmdEvent{Type: SteamCmdLoginFailed, Reason: reason}
+}
+
+// steamCmdEvent reports steamcmd output as download progress, or prints
+// it in verbose mode
+func (m *Manager) steamCmdEvent(event SteamCmdEvent) {
+	if m.progressHandler == nil {
+		m.log(event.String())
+		return
+	}
+
+	progress := ProgressEvent{ID: event.ID, Stage: StageDownload, Total: -1, Message: event.String()}
+	if event.Type == SteamCmdDownloadSuccess {
+		progress.Done, progress.Total = event.Bytes, event.Bytes
+	}
+	m.progress(progress)
+}
@@ -0,0 +1,61 @@
+package addon
+
+import "testing"
+
+func TestParseSteamCmdLine(t *testing.T) {
+	tests := []struct {
+		line string
+		want SteamCmdEvent
+		ok   bool
+	}{
+		{
+			line: "Logging in user 'anonymous' to Steam Public...OK",
+			want: SteamCmdEvent{Type: SteamCmdLogin},
+			ok:   true,
+		},
+		{
+			line: "Logging in user 'anonymous' to Steam Public...FAILED (No Connection)",
+			want: SteamCmdEvent{Type: SteamCmdLoginFailed, Reason: "No Connection"},
+			ok:   true,
+		},
+		{
+			line: "FAILED login with result code Rate Limit Exceeded",
+			want: SteamCmdEvent{Type: SteamCmdLoginFailed, Reason: "Rate Limit Exceeded"},
+			ok:   true,
+		},
+		{
+			line: "FAILED",
+			want: SteamCmdEvent{Type: SteamCmdLoginFailed, Reason: "unknown error"},
+			ok:   true,
+		},
+		{
+			line: "Downloading item 104691717 ...",
+			want: SteamCmdEvent{Type: SteamCmdDownloadStarted, ID: "104691717"},
+			ok:   true,
+		},
+		{
+			line: `Success. Downloaded item 104691717 to "/home/me/steamcmd/steamapps/workshop/content/4000/104691717" (2048 bytes)`,
+			want: SteamCmdEvent{
+				Type:  SteamCmdDownloadSuccess,
+				ID:    "104691717",
+				Path:  "/home/me/steamcmd/steamapps/workshop/content/4000/104691717",
+				Bytes: 2048,
+			},
+			ok: true,
+		},
+		{
+			line: "  ERROR! Download item 104691717 failed (Timeout).  ",
+			want: SteamCmdEvent{Type: SteamCmdDownloadFailed, ID: "104691717", Reason: "Timeout"},
+			ok:   true,
+		},
+		{line: "Redirecting stderr to '/home/me/Steam/logs/stderr.txt'"},
+		{line: "[  0%] Checking for available updates..."},
+		{line: ""},
+	}
+	for _, tt := range tests {
+		got, ok := ParseSteamCmdLine(tt.line)
+		if ok != tt.ok || got != tt.want {
+			t.Errorf("ParseSteamCmdLine(%q) = %+v, %v, want %+v, %v", tt.line, got, ok, tt.want, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,160 @@
+package addon
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// OutdatedAddon describes an installed addon with a newer workshop copy
+type OutdatedAddon struct {
+	ID        string
+	Title     string
+	Installed time.Time // workshop revision (or install time) of the local copy
+	Available time.Time // workshop time_updated
+}
+
+// installedRevision returns the time of the installed copy of an addon.
+// Addons installed before revisions were recorded fall back to the
+// modification time of their folder.
+func (m *Manager) installedRevision(id string) time.Time {
+	if entry, ok := m.manifest.Get(id); ok {
+		if entry.TimeUpdated != 0 {
+			return time.Unix(entry.TimeUpdated, 0)
+		}
+		return entry.InstalledAt
+	}
+
+	info, err := os.Stat(filepath.Join(m.config.OutDir, id))
+	if err != nil {
+		return time.Time{}
+	}
+	return info.ModTime()
+}
+
+// isOutdated reports whether the workshop copy is newer than the installed one
+func (m *Manager) isOutdated(id string, workshopAddon *WorkshopAddon) bool {
+	if workshopAddon == nil || workshopAddon.TimeUpdated == 0 {
+		return false
+	}
+	return time.Unix(workshopAddon.TimeUpdated, 0).After(m.installedRevision(id))
+}
+
+// GetOutdatedAddons fetches fresh workshop info for every installed addon
+// and returns the ones with a newer workshop copy
+func (m *Manager) GetOutdatedAddons() ([]OutdatedAddon, error) {
+	entries, err := os.ReadDir(m.config.OutDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read out directory: %w", err)
+	}
+
+	var ids []string
+	for _, entry := range entries {
+		if isAddonDir(entry) {
+			ids = append(ids, entry.Name())
+		}
+	}
+
+	workshopAddons, err := m.RefreshWorkshopAddonsInfo(ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get workshop info: %w", err)
+	}
+
+	var outdated []OutdatedAddon
+	for _, id := range ids {
+		workshopAddon := workshopAddons[id]
+		if !m.isOutdated(id, workshopAddon) {
+			continue
+		}
+		outdated = append(outdated, OutdatedAddon{
+			ID:        id,
+			Title:     workshopAddon.Title,
+			Installed: m.installedRevision(id),
+			Available: time.Unix(workshopAddon.TimeUpdated, 0),
+		})
+	}
+
+	return outdated, nil
+}
+
+// UpdateAddon re-downloads and re-extracts an addon if its workshop copy is
+// newer than the installed one. It reports whether an update was installed.
+func (m *Manager) UpdateAddon(id string) (bool, error) {
+	return m.updateAddon(context.Background(), id)
+}
+
+func (m *Manager) updateAddon(ctx context.Context, id string) (bool, error) {
+	unlock, err := m.lock()
+	if err != nil {
+		return false, err
+	}
+	defer unlock()
+
+	addon := m.localAddonInfo(id)
+	if !addon.Installed {
+		return false, fmt.Errorf("addon %s is not installed", id)
+	}
+
+	workshopAddons, err := m.RefreshWorkshopAddonsInfo([]string{id})
+	if err != nil {
+		return false, fmt.Errorf("failed to get workshop info: %w", err)
+	}
+	if !m.isOutdated(id, workshopAddons[id]) {
+		m.log(fmt.Sprintf("Addon %s is up to date.", id))
+		return false, nil
+	}
+
+	if err := m.reinstallAddon(ctx, addon); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// reinstallAddon replaces the files of an installed addon with a fresh
+// download, keeping its enabled state
+func (m *Manager) reinstallAddon(ctx context.Context, addon *Addon) error {
+	id := addon.ID
+
+	// The install swaps in a fresh folder, so files dropped by the update don't linger
+	m.log(fmt.Sprintf("Updating addon %s...", id))
+	if err := m.installAddon(ctx, id); err != nil {
+		return err
+	}
+
+	// Installing enables the addon, so restore the previous state
+	if !addon.Enabled {
+		return m.DisableAddon(id)
+	}
+	return nil
+}
+
+// UpdateAll updates every outdated addon on the job queue, returning the
+// IDs that were updated and an error for each one that failed
+func (m *Manager) UpdateAll() ([]string, map[string]error, error) {
+	outdated, err := m.GetOutdatedAddons()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	handles := make([]*JobHandle, 0, len(outdated))
+	for _, o := range outdated {
+		handles = append(handles, m.Jobs().Add(context.Background(), o.ID, JobUpdate))
+	}
+
+	var updated []string
+	failed := make(map[string]error)
+	for _, handle := range handles {
+		job := handle.Wait()
+		if job.Err != nil {
+			failed[job.ID] = job.Err
+			continue
+		}
+		if job.Updated {
+			updated = append(updated, job.ID)
+		}
+	}
+
+	return updated, failed, nil
+}
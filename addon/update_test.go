@@ -0,0 +1,56 @@
+package addon
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestIsOutdated(t *testing.T) {
+	installed := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		entry       *ManifestEntry // recorded install, if any
+		folderTime  time.Time      // modification time of the addon folder
+		timeUpdated int64          // workshop time_updated, 0 for no workshop info
+		want        bool
+	}{
+		{name: "no workshop info", entry: &ManifestEntry{TimeUpdated: installed.Unix()}},
+		{name: "same revision", entry: &ManifestEntry{TimeUpdated: installed.Unix()}, timeUpdated: installed.Unix()},
+		{name: "newer revision", entry: &ManifestEntry{TimeUpdated: installed.Unix()}, timeUpdated: installed.Unix() + 1, want: true},
+		{name: "older revision", entry: &ManifestEntry{TimeUpdated: installed.Unix()}, timeUpdated: installed.Unix() - 1},
+		{name: "revision without time_updated", entry: &ManifestEntry{InstalledAt: installed}, timeUpdated: installed.Add(time.Hour).Unix(), want: true},
+		{name: "updated before install", entry: &ManifestEntry{InstalledAt: installed}, timeUpdated: installed.Add(-time.Hour).Unix()},
+		{name: "unrecorded, older than folder", folderTime: installed, timeUpdated: installed.Add(-time.Hour).Unix()},
+		{name: "unrecorded, newer than folder", folderTime: installed, timeUpdated: installed.Add(time.Hour).Unix(), want: true},
+	}
+
+	for _, tt := range tests {
+		m := newTestManager(t)
+		outDir := filepath.Join(m.config.OutDir, "111")
+		if err := os.MkdirAll(outDir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		if !tt.folderTime.IsZero() {
+			if err := os.Chtimes(outDir, tt.folderTime, tt.folderTime); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if tt.entry != nil {
+			tt.entry.ID = "111"
+			if err := m.manifest.Put(*tt.entry); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		var workshopAddon *WorkshopAddon
+		if tt.timeUpdated != 0 {
+			workshopAddon = &WorkshopAddon{PublishedFileID: "111", TimeUpdated: tt.timeUpdated}
+		}
+		if got := m.isOutdated("111", workshopAddon); got != tt.want {
+			t.Errorf("%s: isOutdated = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
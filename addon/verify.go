@@ -0,0 +1,104 @@
+package addon
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gmod-addon-manager/file"
+)
+
+// VerifyResult is the outcome of checking an installed addon against the
+// archive it was extracted from
+type VerifyResult struct {
+	ID      string
+	Archive *file.GMAReport
+	Tree    *file.TreeDiff
+}
+
+// OK reports whether both the archive and the extracted files are intact
+func (r *VerifyResult) OK() bool {
+	return r.Archive.OK() && (r.Tree == nil || r.Tree.OK())
+}
+
+// VerifyAddon checks the downloaded archive of an addon and compares its
+// file table with the extracted tree in OutDir
+func (m *Manager) VerifyAddon(id string) (*VerifyResult, error) {
+	addonDir := filepath.Join(m.config.OutDir, id)
+	if _, err := os.Stat(addonDir); os.IsNotExist(err) {
+		return nil, fmt.Errorf("addon %s is not installed", id)
+	}
+
+	downloadedFilePath, err := m.archivePath(id)
+	if err != nil {
+		return nil, err
+	}
+
+	// Legacy .bin downloads have to be decompressed before they can be read
+	gmaPath := downloadedFilePath
+	if !hasSuffixFold(downloadedFilePath, ".gma") {
+		// Use a directory of our own so a running install of the addon isn't disturbed
+		if err := os.MkdirAll(m.config.TmpDir, 0755); err != nil {
+			return nil, fmt.Errorf("failed to create tmp directory: %w", err)
+		}
+		tmpDir, err := os.MkdirTemp(m.config.TmpDir, id+"-verify-")
+		if err != nil {
+			return nil, fmt.Errorf("failed to create tmp directory: %w", err)
+		}
+		defer os.RemoveAll(tmpDir)
+
+		gmaPath = filepath.Join(tmpDir, id+".gma")
+		if err := prepareGMA(downloadedFilePath, gmaPath, nil); err != nil {
+			return nil, err
+		}
+	}
+
+	m.log(fmt.Sprintf("Verifying addon %s...", id))
+	report, err := file.VerifyGMA(gmaPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to verify gma file: %w", err)
+	}
+	result := &VerifyResult{ID: id, Archive: report}
+
+	// A corrupt archive can't be trusted as a reference for the extracted files
+	if report.Truncated {
+		return result, nil
+	}
+
+	result.Tree, err = file.CompareGMATree(report.GMA, addonDir)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// archivePath returns the downloaded file an addon was installed from,
+// falling back to the steamcmd download directory
+func (m *Manager) archivePath(id string) (string, error) {
+	if entry, ok := m.manifest.Get(id); ok && entry.Archive != "" {
+		if _, err := os.Stat(entry.Archive); err == nil {
+			return entry.Archive, nil
+		}
+	}
+	return firstDownloadedFile(m.config.DownloadDir, id)
+}
+
+// describeGMAReport summarizes what is wrong with an archive
+func describeGMAReport(report *file.GMAReport) string {
+	var problems []string
+	if report.Truncated {
+		problems = append(problems, "archive is truncated")
+	}
+	if len(report.CorruptFiles) > 0 {
+		problems = append(problems, fmt.Sprintf("%d file(s) fail their CRC check", len(report.CorruptFiles)))
+	}
+	if report.HasCRC && report.CRC != report.ComputedCRC {
+		problems = append(problems, fmt.Sprintf("archive CRC mismatch (expected %08x, got %08x)", report.CRC, report.ComputedCRC))
+	}
+	if len(problems) == 0 {
+		return "ok"
+	}
+	return strings.Join(problems, ", ")
+}
@@ -0,0 +1,99 @@
+package addon
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gmod-addon-manager/file"
+)
+
+// placeDownloadedAddon puts an archive where steamcmd would have downloaded
+// it and extracts it into OutDir
+func placeDownloadedAddon(t *testing.T, m *Manager, id string) string {
+	t.Helper()
+	data, err := os.ReadFile(writeTestGMA(t, "print('hello')"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	archive := filepath.Join(m.config.DownloadDir, id, "test.gma")
+	if err := os.MkdirAll(filepath.Dir(archive), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(archive, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := file.ExtractGMA(archive, filepath.Join(m.config.OutDir, id)); err != nil {
+		t.Fatal(err)
+	}
+	return archive
+}
+
+func TestVerifyAddon(t *testing.T) {
+	tests := []struct {
+		name    string
+		change  func(t *testing.T, archive, addonDir string)
+		ok      bool
+		archive bool // the archive itself passes
+	}{
+		{
+			name:    "intact",
+			change:  func(*testing.T, string, string) {},
+			ok:      true,
+			archive: true,
+		},
+		{
+			name: "modified file",
+			change: func(t *testing.T, _, addonDir string) {
+				if err := os.WriteFile(filepath.Join(addonDir, "lua", "autorun", "test.lua"), []byte("print('HELLO')"), 0644); err != nil {
+					t.Fatal(err)
+				}
+			},
+			archive: true,
+		},
+		{
+			name: "missing file",
+			change: func(t *testing.T, _, addonDir string) {
+				if err := os.Remove(filepath.Join(addonDir, "lua", "autorun", "test.lua")); err != nil {
+					t.Fatal(err)
+				}
+			},
+			archive: true,
+		},
+		{
+			name: "corrupt archive",
+			change: func(t *testing.T, archive, _ string) {
+				data, err := os.ReadFile(archive)
+				if err != nil {
+					t.Fatal(err)
+				}
+				data[len(data)-5] ^= 0xff // last byte of the file contents
+				if err := os.WriteFile(archive, data, 0644); err != nil {
+					t.Fatal(err)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		m := newTestManager(t)
+		archive := placeDownloadedAddon(t, m, "111")
+		tt.change(t, archive, filepath.Join(m.config.OutDir, "111"))
+
+		result, err := m.VerifyAddon("111")
+		if err != nil {
+			t.Errorf("%s: VerifyAddon: %v", tt.name, err)
+			continue
+		}
+		if result.OK() != tt.ok || result.Archive.OK() != tt.archive {
+			t.Errorf("%s: result = %+v (archive %+v, tree %+v), want ok %v", tt.name, result, result.Archive, result.Tree, tt.ok)
+		}
+	}
+}
+
+func TestVerifyAddonNotInstalled(t *testing.T) {
+	m := newTestManager(t)
+	if _, err := m.VerifyAddon("111"); err == nil {
+		t.Error("verifying an addon that isn't installed succeeded")
+	}
+}
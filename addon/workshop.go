@@ -0,0 +1,266 @@
+package addon
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Steam Web API endpoints, variables so tests can serve them locally
+var (
+	publishedFileDetailsURL = "https://api.steampowered.com/ISteamRemoteStorage/GetPublishedFileDetails/v1/"
+	collectionDetailsURL    = "https://api.steampowered.com/ISteamRemoteStorage/GetCollectionDetails/v1/"
+)
+
+// resultOK is Steam's EResult value for a successful lookup
+const resultOK = 1
+
+// gmodAppID is the Steam app ID of Garry's Mod
+const gmodAppID = 4000
+
+// workshopBatchSize is the number of items requested per API call
+const workshopBatchSize = 100
+
+// isWorkshopID reports whether id looks like a published file ID, as
+// opposed to the local IDs of addons installed from a file
+func isWorkshopID(id string) bool {
+	_, err := strconv.ParseUint(id, 10, 64)
+	return err == nil
+}
+
+// Helper function to get addon info from Steam Workshop with caching
+func (m *Manager) getWorkshopAddonInfo(id string) (*WorkshopAddon, error) {
+	workshopAddons, err := m.GetWorkshopAddonsInfo([]string{id})
+	if err != nil {
+		return nil, err
+	}
+	return workshopAddons[id], nil
+}
+
+// GetWorkshopAddonsInfo looks up many addons at once. Fresh cache entries
+// are used as-is and the rest are fetched in batches and written to the
+// cache. When a request fails, expired entries are returned marked Stale;
+// the error is only returned if some addons couldn't be resolved at all.
+func (m *Manager) GetWorkshopAddonsInfo(ids []string) (map[string]*WorkshopAddon, error) {
+	workshopAddons := make(map[string]*WorkshopAddon, len(ids))
+
+	// Check cache first
+	var missing []string
+	stale := make(map[string]*WorkshopAddon)
+	seen := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		if seen[id] || !isWorkshopID(id) {
+			continue
+		}
+		seen[id] = true
+
+		// An unreadable cache entry is treated as a miss and overwritten
+		cachedAddon, found, err := m.cache.Get(id)
+		if err == nil && found {
+			if !cachedAddon.Stale {
+				workshopAddons[id] = cachedAddon
+				continue
+			}
+			stale[id] = cachedAddon
+		}
+		missing = append(missing, id)
+	}
+
+	err := m.refreshWorkshopAddons(missing, workshopAddons)
+	if err == nil {
+		return workshopAddons, nil
+	}
+
+	// Fall back to expired entries for what couldn't be refetched
+	resolved := true
+	for _, id := range missing {
+		if workshopAddons[id] != nil {
+			continue
+		}
+		if stale[id] != nil {
+			workshopAddons[id] = stale[id]
+			continue
+		}
+		resolved = false
+	}
+	if resolved {
+		m.log(fmt.Sprintf("Using cached workshop info, the workshop could not be reached: %v", err))
+		return workshopAddons, nil
+	}
+	return workshopAddons, err
+}
+
+// RefreshWorkshopAddonsInfo fetches many addons from the workshop, bypassing
+// and then updating the cache
+func (m *Manager) RefreshWorkshopAddonsInfo(ids []string) (map[string]*WorkshopAddon, error) {
+	workshopAddons := make(map[string]*WorkshopAddon, len(ids))
+	err := m.refreshWorkshopAddons(ids, workshopAddons)
+	return workshopAddons, err
+}
+
+// refreshWorkshopAddons fetches ids in batches, caching each result and
+// adding it to workshopAddons
+func (m *Manager) refreshWorkshopAddons(ids []string, workshopAddons map[string]*WorkshopAddon) error {
+	ids = slices.DeleteFunc(slices.Clone(ids), func(id string) bool { return !isWorkshopID(id) })
+
+	for start := 0; start < len(ids); start += workshopBatchSize {
+		end := min(start+workshopBatchSize, len(ids))
+		fetched, err := m.fetchWorkshopAddons(ids[start:end])
+		if err != nil {
+			return err
+		}
+
+		for i := range fetched {
+			workshopAddon := &fetched[i]
+
+			// Items that are private, removed or unknown come back without details
+			if workshopAddon.Result != resultOK {
+				continue
+			}
+			workshopAddons[workshopAddon.PublishedFileID] = workshopAddon
+
+			// Cache the result
+			if err := m.cache.Set(workshopAddon.PublishedFileID, workshopAddon); err != nil {
+				return fmt.Errorf("failed to cache workshop addon: %w", err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// fetchWorkshopAddons requests the details of up to workshopBatchSize items
+// in a single GetPublishedFileDetails call
+func (m *Manager) fetchWorkshopAddons(ids []string) ([]WorkshopAddon, error) {
+	form := url.Values{}
+	form.Set("itemcount", strconv.Itoa(len(ids)))
+	for i, id := range ids {
+		form.Set(fmt.Sprintf("publishedfileids[%d]", i), id)
+	}
+	if m.config.SteamAPIKey != "" {
+		form.Set("key", m.config.SteamAPIKey)
+	}
+
+	var result WorkshopResponse
+	if err := postSteamAPI(publishedFileDetailsURL, form, &result); err != nil {
+		return nil, err
+	}
+
+	return result.Response.PublishedFileDetails, nil
+}
+
+// fetchCollectionChildren returns the direct children of each collection
+func (m *Manager) fetchCollectionChildren(ids []string) (map[string][]CollectionChild, error) {
+	form := url.Values{}
+	form.Set("collectioncount", strconv.Itoa(len(ids)))
+	for i, id := range ids {
+		form.Set(fmt.Sprintf("publishedfileids[%d]", i), id)
+	}
+	if m.config.SteamAPIKey != "" {
+		form.Set("key", m.config.SteamAPIKey)
+	}
+
+	var result CollectionResponse
+	if err := postSteamAPI(collectionDetailsURL, form, &result); err != nil {
+		return nil, err
+	}
+
+	children := make(map[string][]CollectionChild, len(ids))
+	for _, details := range result.Response.CollectionDetails {
+		if details.Result == resultOK {
+			children[details.PublishedFileID] = details.Children
+		}
+	}
+	return children, nil
+}
+
+// postSteamAPI sends a form-encoded request to a Steam Web API endpoint and
+// decodes the JSON response into result
+func postSteamAPI(apiURL string, form url.Values, result any) error {
+	resp, err := http.Post(apiURL, "application/x-www-form-urlencoded", strings.NewReader(form.Encode()))
+	if err != nil {
+		return fmt.Errorf("failed to make API request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("API request failed: %s", resp.Status)
+	}
+
+	if err := json.Unmarshal(body, result); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	return nil
+}
+
+// Steam Workshop API response structures
+type WorkshopResponse struct {
+	Response struct {
+		PublishedFileDetails []WorkshopAddon `json:"publishedfiledetails"`
+	} `json:"response"`
+}
+
+type WorkshopAddon struct {
+	PublishedFileID string `json:"publishedfileid"`
+	Result          int    `json:"result"`
+	ConsumerAppID   int    `json:"consumer_app_id"`
+	Title           string `json:"title"`
+	Creator         string `json:"creator"`
+	TimeCreated     int64  `json:"time_created"`
+	TimeUpdated     int64  `json:"time_updated"`
+	Views           int    `json:"views"`
+	Subscriptions   int    `json:"subscriptions"`
+	Favorited       int    `json:"favorited"`
+	Tags            []Tag  `json:"tags"`
+	Description     string `json:"description"`
+	Filename        string `json:"filename"`
+	FileURL         string `json:"file_url"`
+
+	// Stale is set on info from an expired cache entry
+	Stale bool `json:"-"`
+}
+
+type CollectionResponse struct {
+	Response struct {
+		CollectionDetails []CollectionDetails `json:"collectiondetails"`
+	} `json:"response"`
+}
+
+type CollectionDetails struct {
+	PublishedFileID string            `json:"publishedfileid"`
+	Result          int               `json:"result"`
+	Children        []CollectionChild `json:"children"`
+}
+
+// fileTypeCollection marks a collection child that is itself a collection
+const fileTypeCollection = 2
+
+type CollectionChild struct {
+	PublishedFileID string `json:"publishedfileid"`
+	SortOrder       int    `json:"sortorder"`
+	FileType        int    `json:"filetype"`
+}
+
+type Tag struct {
+	Tag string `json:"tag"`
+}
+
+// Method to convert []Tag to []string
+func (w *WorkshopAddon) GetTagsAsStrings() []string {
+	tags := make([]string, len(w.Tags))
+	for i, tag := range w.Tags {
+		tags[i] = tag.Tag
+	}
+	return tags
+}
@@ -0,0 +1,150 @@
+package addon
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+)
+
+// serveSteamAPI points the Steam Web API endpoint at a local server for the
+// duration of the test
+func serveSteamAPI(t *testing.T, endpoint *string, handler http.HandlerFunc) {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	original := *endpoint
+	*endpoint = server.URL
+	t.Cleanup(func() { *endpoint = original })
+}
+
+// publishedFileDetailsHandler answers GetPublishedFileDetails requests,
+// recording the size of each batch. IDs in missing come back without details,
+// like private or removed items.
+func publishedFileDetailsHandler(t *testing.T, batches *[]int, missing map[string]bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Errorf("parsing request: %v", err)
+		}
+		count, err := strconv.Atoi(r.PostForm.Get("itemcount"))
+		if err != nil {
+			t.Errorf("bad itemcount %q", r.PostForm.Get("itemcount"))
+		}
+		*batches = append(*batches, count)
+
+		var response WorkshopResponse
+		for i := range count {
+			id := r.PostForm.Get(fmt.Sprintf("publishedfileids[%d]", i))
+			addon := WorkshopAddon{PublishedFileID: id, Result: resultOK, Title: "Addon " + id}
+			if missing[id] {
+				addon = WorkshopAddon{PublishedFileID: id, Result: 9}
+			}
+			response.Response.PublishedFileDetails = append(response.Response.PublishedFileDetails, addon)
+		}
+		json.NewEncoder(w).Encode(response)
+	}
+}
+
+func TestGetWorkshopAddonsInfo(t *testing.T) {
+	ids := func(n int) []string {
+		ids := make([]string, n)
+		for i := range ids {
+			ids[i] = strconv.Itoa(1000 + i)
+		}
+		return ids
+	}
+
+	tests := []struct {
+		name    string
+		ids     []string
+		cached  []string
+		missing map[string]bool
+		batches []int
+		found   int
+	}{
+		{name: "single", ids: ids(1), batches: []int{1}, found: 1},
+		{name: "full batch", ids: ids(100), batches: []int{100}, found: 100},
+		{name: "several batches", ids: ids(250), batches: []int{100, 100, 50}, found: 250},
+		{name: "duplicates", ids: append(ids(3), ids(3)...), batches: []int{3}, found: 3},
+		{name: "cached", ids: ids(150), cached: ids(60), batches: []int{90}, found: 150},
+		{name: "all cached", ids: ids(5), cached: ids(5), found: 5},
+		{name: "unavailable items", ids: ids(3), missing: map[string]bool{"1001": true}, batches: []int{3}, found: 2},
+	}
+
+	for _, tt := range tests {
+		m := newTestManager(t)
+		for _, id := range tt.cached {
+			if err := m.cache.Set(id, &WorkshopAddon{PublishedFileID: id, Result: resultOK}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		var batches []int
+		serveSteamAPI(t, &publishedFileDetailsURL, publishedFileDetailsHandler(t, &batches, tt.missing))
+
+		workshopAddons, err := m.GetWorkshopAddonsInfo(tt.ids)
+		if err != nil {
+			t.Errorf("%s: GetWorkshopAddonsInfo: %v", tt.name, err)
+			continue
+		}
+		if len(workshopAddons) != tt.found {
+			t.Errorf("%s: found %d addons, want %d", tt.name, len(workshopAddons), tt.found)
+		}
+		if fmt.Sprint(batches) != fmt.Sprint(tt.batches) {
+			t.Errorf("%s: batches = %v, want %v", tt.name, batches, tt.batches)
+		}
+		for id := range tt.missing {
+			if _, found, _ := m.cache.Get(id); found {
+				t.Errorf("%s: unavailable item %s was cached", tt.name, id)
+			}
+		}
+	}
+}
+
+func TestGetWorkshopAddonsInfoError(t *testing.T) {
+	m := newTestManager(t)
+	serveSteamAPI(t, &publishedFileDetailsURL, func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "busy", http.StatusServiceUnavailable)
+	})
+
+	if _, err := m.GetWorkshopAddonsInfo([]string{"1000"}); err == nil {
+		t.Error("GetWorkshopAddonsInfo succeeded against a failing API")
+	}
+}
+
+func TestGetWorkshopAddonsInfoStale(t *testing.T) {
+	m := newTestManager(t)
+	m.cache.ttl = 0 // entries expire as soon as they are written
+	if err := m.cache.Set("1000", &WorkshopAddon{PublishedFileID: "1000", Result: resultOK, Title: "Cached"}); err != nil {
+		t.Fatal(err)
+	}
+
+	serveSteamAPI(t, &publishedFileDetailsURL, func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "busy", http.StatusServiceUnavailable)
+	})
+	workshopAddons, err := m.GetWorkshopAddonsInfo([]string{"1000"})
+	if err != nil {
+		t.Fatalf("GetWorkshopAddonsInfo with a stale entry: %v", err)
+	}
+	if addon := workshopAddons["1000"]; addon == nil || addon.Title != "Cached" || !addon.Stale {
+		t.Errorf("addon = %+v, want the stale cached entry", addon)
+	}
+
+	// Items without any cached copy still fail
+	if _, err := m.GetWorkshopAddonsInfo([]string{"1000", "1001"}); err == nil {
+		t.Error("GetWorkshopAddonsInfo succeeded without info on 1001")
+	}
+
+	// Once the workshop answers the stale entry is replaced
+	var batches []int
+	serveSteamAPI(t, &publishedFileDetailsURL, publishedFileDetailsHandler(t, &batches, nil))
+	workshopAddons, err = m.GetWorkshopAddonsInfo([]string{"1000"})
+	if err != nil {
+		t.Fatalf("GetWorkshopAddonsInfo: %v", err)
+	}
+	if addon := workshopAddons["1000"]; addon == nil || addon.Title != "Addon 1000" || addon.Stale {
+		t.Errorf("addon = %+v, want the refetched entry", addon)
+	}
+}
@@ -5,6 +5,9 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
 )
 
 type Config struct {
@@ -16,62 +19,115 @@ type Config struct {
 	SteamCmdPath string `json:"steamcmd_path"`
 	GMADPath     string `json:"gmad_path"`
 	SteamAPIKey  string `json:"steam_api_key"`
+
+	// Downloaders lists the download backends to try, in order:
+	// "steamcmd", "http" (public file_url) and "local" (MirrorDir)
+	Downloaders []string `json:"downloaders"`
+	MirrorDir   string   `json:"mirror_dir"`
+
+	// Workers is how many installs and updates run at the same time
+	Workers int `json:"workers"`
+
+	// CacheTTL is how long workshop info is cached, as a duration like "24h".
+	// Expired entries are still used when the workshop can't be reached.
+	CacheTTL string `json:"cache_ttl"`
+
+	// Targets are other installs managed from this config, selected with
+	// --target or GMAM_TARGET. The top-level paths are the "default" target.
+	Targets       map[string]Target `json:"targets,omitempty"`
+	DefaultTarget string            `json:"default_target,omitempty"`
+
+	// Target is the name of the selected target, it isn't saved
+	Target string `json:"-"`
+
+	// Sources records where each setting came from, by JSON key. Settings
+	// missing from it are defaults.
+	Sources map[string]string `json:"-"`
 }
 
 const ConfigFileName = "gmod-addon-manager.json"
 
+// DefaultWorkers is the number of concurrent installs when none is configured
+const DefaultWorkers = 2
+
+// DefaultCacheTTL is how long workshop info is cached when no TTL is configured
+const DefaultCacheTTL = "24h"
+
 func NewDefaultConfig() *Config {
-	homeDir, _ := os.UserHomeDir()
+	detected := Detect()
+	gmodDir := defaultGModDir(detected)
 
 	return &Config{
-		GModDir:      "C:\\Games\\GarrysMod",
-		DownloadDir:  filepath.Join(homeDir, "AppData", "Local", "Microsoft", "WinGet", "Packages", "Valve.SteamCMD_Microsoft.Winget.Source_8wekyb3d8bbwe", "steamapps", "workshop", "content", "4000"),
+		GModDir:      gmodDir,
+		DownloadDir:  defaultDownloadDir(detected),
 		AddonDir:     "",
 		OutDir:       "",
 		TmpDir:       "",
-		SteamCmdPath: "steamcmd.exe",
-		GMADPath:     "",
+		SteamCmdPath: defaultSteamCmdPath(detected),
+		GMADPath:     defaultGMADPath(gmodDir),
 		SteamAPIKey:  "",
+		Downloaders:  []string{"steamcmd"},
+		MirrorDir:    "",
+		Workers:      DefaultWorkers,
+		CacheTTL:     DefaultCacheTTL,
 	}
 }
 
+// LoadConfig loads the config of the target selected by GMAM_TARGET or
+// default_target, with GMAM_* environment variables applied
 func LoadConfig() (*Config, error) {
+	return LoadConfigTarget("", nil)
+}
+
+// LoadConfigTarget loads the config with the paths of the named target, or
+// the one selected by GMAM_TARGET or default_target when name is empty.
+//
+// Settings are taken from, highest precedence first: flags, GMAM_*
+// environment variables, the target in the config file, the top level of
+// the config file, and finally detected or derived defaults.
+func LoadConfigTarget(name string, flags Overrides) (*Config, error) {
 	// Get config file path
 	configPath, err := getConfigPath()
 	if err != nil {
 		return nil, fmt.Errorf("failed to get config path: %w", err)
 	}
 
-	// Check if config file exists
+	var config *Config
 	if _, err := os.Stat(configPath); os.IsNotExist(err) {
-		// Create default config and save it
-		config := NewDefaultConfig()
-		if err := SaveConfig(config); err != nil {
-			return nil, fmt.Errorf("failed to save default config: %w", err)
+		// Create default config and save it, unless the run is configured
+		// by flags or GMAM_* variables and a file would only get in the way.
+		// The defaults work without a file, so a config directory that
+		// can't be written to isn't an error either.
+		config = NewDefaultConfig()
+		if !hasOverrides(flags) {
+			SaveConfig(config)
 		}
-		return fillInDefaultPaths(config), nil
+	} else if config, err = LoadConfigFile(); err != nil {
+		return nil, err
 	}
 
-	// Read config file
-	data, err := os.ReadFile(configPath)
+	selected, err := config.ForTarget(config.ResolveTarget(name))
 	if err != nil {
-		return nil, fmt.Errorf("failed to read config file: %w", err)
+		return nil, err
 	}
-
-	// Parse config
-	var config Config
-	if err := json.Unmarshal(data, &config); err != nil {
-		return nil, fmt.Errorf("failed to parse config file: %w", err)
+	if err := applyOverrides(selected, flags); err != nil {
+		return nil, err
 	}
 
 	// Fill in any missing paths based on GModDir
-	return fillInDefaultPaths(&config), nil
+	return fillInDefaultPaths(selected), nil
 }
 
 func fillInDefaultPaths(config *Config) *Config {
-	// If GModDir is empty, use default
+	// Only look for Steam installs when something is missing
+	var detected Detected
+	if config.GModDir == "" || config.DownloadDir == "" || config.SteamCmdPath == "" {
+		detected = Detect()
+	}
+
+	// If GModDir is empty, use the detected install
 	if config.GModDir == "" {
-		config.GModDir = "C:\\Games\\GarrysMod"
+		config.GModDir = defaultGModDir(detected)
 	}
 
 	// Fill in AddonDir if empty
@@ -91,13 +147,29 @@ func fillInDefaultPaths(config *Config) *Config {
 
 	// Fill in GMADPath if empty
 	if config.GMADPath == "" {
-		config.GMADPath = filepath.Join(config.GModDir, "bin", "gmad.exe")
+		config.GMADPath = defaultGMADPath(config.GModDir)
 	}
 
-	// Ensure DownloadDir is set (this one can't be deduced from GModDir)
+	// DownloadDir can't be deduced from GModDir, it comes from steamcmd
 	if config.DownloadDir == "" {
-		homeDir, _ := os.UserHomeDir()
-		config.DownloadDir = filepath.Join(homeDir, "AppData", "Local", "Microsoft", "WinGet", "Packages", "Valve.SteamCMD_Microsoft.Winget.Source_8wekyb3d8bbwe", "steamapps", "workshop", "content", "4000")
+		config.DownloadDir = defaultDownloadDir(detected)
+	}
+
+	if config.SteamCmdPath == "" {
+		config.SteamCmdPath = defaultSteamCmdPath(detected)
+	}
+
+	// Default to steamcmd only
+	if len(config.Downloaders) == 0 {
+		config.Downloaders = []string{"steamcmd"}
+	}
+
+	if config.Workers < 1 {
+		config.Workers = DefaultWorkers
+	}
+
+	if config.CacheTTL == "" {
+		config.CacheTTL = DefaultCacheTTL
 	}
 
 	return config
@@ -131,6 +203,14 @@ func SaveConfig(config *Config) error {
 }
 
 func getConfigPath() (string, error) {
+	// --config wins over GMAM_CONFIG
+	if configPathOverride != "" {
+		return configPathOverride, nil
+	}
+	if path := os.Getenv(ConfigEnv); path != "" {
+		return path, nil
+	}
+
 	// Get user's config directory
 	configDir, err := os.UserConfigDir()
 	if err != nil {
@@ -147,3 +227,43 @@ func getConfigPath() (string, error) {
 func GetConfigPath() (string, error) {
 	return getConfigPath()
 }
+
+// CacheTTLDuration returns the cache TTL, or the default if it is invalid
+func (c *Config) CacheTTLDuration() time.Duration {
+	ttl, err := parseCacheTTL(c.CacheTTL)
+	if err != nil || c.CacheTTL == "" {
+		ttl, _ = parseCacheTTL(DefaultCacheTTL)
+	}
+	return ttl
+}
+
+func parseCacheTTL(value string) (time.Duration, error) {
+	ttl, err := ParseDuration(value)
+	if err != nil || ttl < 0 {
+		return 0, fmt.Errorf("cache_ttl must be a duration like 7d, 24h or 30m")
+	}
+	return ttl, nil
+}
+
+// ParseDuration parses a duration like time.ParseDuration does, also
+// accepting a number of days in front, as in 7d or 1d12h
+func ParseDuration(value string) (time.Duration, error) {
+	days, rest, ok := strings.Cut(value, "d")
+	if !ok {
+		return time.ParseDuration(value)
+	}
+
+	n, err := strconv.ParseUint(days, 10, 16)
+	if err != nil {
+		return 0, fmt.Errorf("invalid number of days in duration %q", value)
+	}
+	ttl := time.Duration(n) * 24 * time.Hour
+	if rest == "" {
+		return ttl, nil
+	}
+	d, err := time.ParseDuration(rest)
+	if err != nil {
+		return 0, err
+	}
+	return ttl + d, nil
+}
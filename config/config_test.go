@@ -0,0 +1,33 @@
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseCacheTTL(t *testing.T) {
+	tests := []struct {
+		value string
+		want  time.Duration
+		ok    bool
+	}{
+		{"24h", 24 * time.Hour, true},
+		{"30m", 30 * time.Minute, true},
+		{"7d", 7 * 24 * time.Hour, true},
+		{"1d12h", 36 * time.Hour, true},
+		{"0d", 0, true},
+		{"0", 0, true},
+		{"d", 0, false},
+		{"1.5d", 0, false},
+		{"-1d", 0, false},
+		{"-1h", 0, false},
+		{"7days", 0, false},
+		{"soon", 0, false},
+	}
+	for _, tt := range tests {
+		got, err := parseCacheTTL(tt.value)
+		if (err == nil) != tt.ok || got != tt.want {
+			t.Errorf("parseCacheTTL(%q) = %s, %v, want %s (ok %v)", tt.value, got, err, tt.want, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+
+	"gmod-addon-manager/steam"
+)
+
+// Detected holds the paths found by looking for Steam, Garry's Mod and
+// steamcmd installs. Fields are empty when nothing was found.
+type Detected struct {
+	GModDir      string
+	GMADPath     string
+	SteamCmdPath string
+	DownloadDir  string
+}
+
+// Detect looks for a Garry's Mod install in the Steam libraries and for
+// steamcmd, deriving the paths the config needs from them
+func Detect() Detected {
+	var detected Detected
+
+	if gmod, err := steam.FindGMod(); err == nil {
+		detected.GModDir = gmod.Dir
+		detected.GMADPath = steam.FindGMAD(gmod.Dir)
+	}
+	if steamCmd, err := steam.FindSteamCmd(); err == nil {
+		detected.SteamCmdPath = steamCmd.Path
+		detected.DownloadDir = steamCmd.DownloadDir()
+	}
+
+	return detected
+}
+
+// Fallbacks used when detection finds nothing
+
+func defaultGModDir(detected Detected) string {
+	if detected.GModDir != "" {
+		return detected.GModDir
+	}
+	if runtime.GOOS == "windows" {
+		return "C:\\Games\\GarrysMod"
+	}
+	homeDir, _ := os.UserHomeDir()
+	return filepath.Join(homeDir, ".local", "share", "Steam", "steamapps", "common", "GarrysMod")
+}
+
+func defaultDownloadDir(detected Detected) string {
+	if detected.DownloadDir != "" {
+		return detected.DownloadDir
+	}
+	homeDir, _ := os.UserHomeDir()
+	if runtime.GOOS == "windows" {
+		return filepath.Join(homeDir, "AppData", "Local", "Microsoft", "WinGet", "Packages", "Valve.SteamCMD_Microsoft.Winget.Source_8wekyb3d8bbwe", "steamapps", "workshop", "content", "4000")
+	}
+	return filepath.Join(homeDir, ".steam", "steamcmd", "steamapps", "workshop", "content", "4000")
+}
+
+func defaultSteamCmdPath(detected Detected) string {
+	if detected.SteamCmdPath != "" {
+		return detected.SteamCmdPath
+	}
+	if runtime.GOOS == "windows" {
+		return "steamcmd.exe"
+	}
+	return "steamcmd"
+}
+
+func defaultGMADPath(gmodDir string) string {
+	if path := steam.FindGMAD(gmodDir); path != "" {
+		return path
+	}
+	if runtime.GOOS == "windows" {
+		return filepath.Join(gmodDir, "bin", "gmad.exe")
+	}
+	return filepath.Join(gmodDir, "bin", "linux64", "gmad")
+}
@@ -0,0 +1,104 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// ConfigEnv points at a config file to use instead of the default one
+const ConfigEnv = "GMAM_CONFIG"
+
+// Where the effective value of a setting came from. Overridden settings
+// name the flag or environment variable instead.
+const (
+	SourceDefault = "default" // detected, or derived from gmod_dir
+	SourceFile    = "config file"
+)
+
+// configPathOverride is the file given with --config
+var configPathOverride string
+
+// SetConfigPath makes the config be read from and saved to path instead of
+// the default location or GMAM_CONFIG
+func SetConfigPath(path string) {
+	configPathOverride = path
+}
+
+// Overrides are settings given on the command line, by JSON key
+type Overrides map[string]string
+
+// EnvVar returns the environment variable overriding the setting, e.g.
+// GMAM_GMOD_DIR
+func (s Setting) EnvVar() string {
+	return "GMAM_" + strings.ToUpper(s.Key)
+}
+
+// Flag returns the name of the command line flag overriding the setting,
+// e.g. gmod-dir. Secrets have none, they would show up in process lists.
+func (s Setting) Flag() string {
+	if s.Secret {
+		return ""
+	}
+	return strings.ReplaceAll(s.Key, "_", "-")
+}
+
+// Source returns where the effective value of a setting came from
+func (c *Config) Source(key string) string {
+	if source, ok := c.Sources[key]; ok {
+		return source
+	}
+	return SourceDefault
+}
+
+// hasOverrides reports whether any setting is given by a flag or a GMAM_*
+// environment variable
+func hasOverrides(flags Overrides) bool {
+	if len(flags) > 0 {
+		return true
+	}
+	for _, setting := range Settings {
+		if os.Getenv(setting.EnvVar()) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// applyOverrides records which settings the config file set and replaces
+// them with environment variables, then flags. Settings left empty are
+// filled in with defaults afterwards.
+func applyOverrides(c *Config, flags Overrides) error {
+	c.Sources = make(map[string]string)
+	for _, setting := range Settings {
+		if setting.Get(c) == "" {
+			continue
+		}
+		c.Sources[setting.Key] = SourceFile
+		if isTargetKey(setting.Key) && c.Target != DefaultTargetName {
+			c.Sources[setting.Key] = "target " + c.Target
+		}
+	}
+
+	for _, setting := range Settings {
+		if value := os.Getenv(setting.EnvVar()); value != "" {
+			if err := setting.Set(c, value); err != nil {
+				return fmt.Errorf("%s: %w", setting.EnvVar(), err)
+			}
+			c.Sources[setting.Key] = "env " + setting.EnvVar()
+		}
+	}
+
+	for key, value := range flags {
+		setting, err := LookupSetting(key)
+		if err != nil {
+			return err
+		}
+		if err := setting.Set(c, value); err != nil {
+			return fmt.Errorf("--%s: %w", setting.Flag(), err)
+		}
+		c.Sources[setting.Key] = "flag --" + setting.Flag()
+	}
+
+	return nil
+}
@@ -0,0 +1,119 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// clearOverrides unsets every GMAM_* variable for the test
+func clearOverrides(t *testing.T) {
+	t.Helper()
+	for _, setting := range Settings {
+		t.Setenv(setting.EnvVar(), "")
+	}
+	t.Setenv(TargetEnv, "")
+}
+
+func TestApplyOverrides(t *testing.T) {
+	clearOverrides(t)
+	t.Setenv("GMAM_WORKERS", "4")
+	t.Setenv("GMAM_ADDON_DIR", "/env/addons")
+
+	file := newTargetConfig()
+	file.MirrorDir = "/file/mirror"
+	selected, err := file.ForTarget("server")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := applyOverrides(selected, Overrides{"workers": "6", "tmp_dir": "/flag/tmp"}); err != nil {
+		t.Fatalf("applyOverrides: %v", err)
+	}
+
+	// Flags win over GMAM_* variables, which win over the target and then
+	// the top level of the file
+	tests := []struct {
+		key, value, source string
+	}{
+		{"workers", "6", "flag --workers"},
+		{"tmp_dir", "/flag/tmp", "flag --tmp-dir"},
+		{"addon_dir", "/env/addons", "env GMAM_ADDON_DIR"},
+		{"gmod_dir", "/srv/gmod", "target server"},
+		{"mirror_dir", "/file/mirror", SourceFile},
+		{"download_dir", "", SourceDefault},
+	}
+	for _, tt := range tests {
+		setting, err := LookupSetting(tt.key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := setting.Get(selected); got != tt.value {
+			t.Errorf("%s = %q, want %q", tt.key, got, tt.value)
+		}
+		if got := selected.Source(tt.key); got != tt.source {
+			t.Errorf("source of %s = %q, want %q", tt.key, got, tt.source)
+		}
+	}
+}
+
+func TestApplyOverridesInvalid(t *testing.T) {
+	clearOverrides(t)
+	t.Setenv("GMAM_WORKERS", "many")
+	if err := applyOverrides(&Config{}, nil); err == nil || !strings.Contains(err.Error(), "GMAM_WORKERS") {
+		t.Errorf("invalid environment variable error = %v, want one naming GMAM_WORKERS", err)
+	}
+
+	clearOverrides(t)
+	if err := applyOverrides(&Config{}, Overrides{"workers": "many"}); err == nil || !strings.Contains(err.Error(), "--workers") {
+		t.Errorf("invalid flag error = %v, want one naming --workers", err)
+	}
+	if err := applyOverrides(&Config{}, Overrides{"nope": "1"}); err == nil {
+		t.Error("an unknown key was accepted")
+	}
+}
+
+// useTestConfigPath makes the config be read from a file in a temp
+// directory that doesn't exist yet, and keeps detection inside it
+func useTestConfigPath(t *testing.T) string {
+	t.Helper()
+	root := t.TempDir()
+	t.Setenv("HOME", root)
+	t.Setenv("PATH", "")
+	path := filepath.Join(root, "config", ConfigFileName)
+	SetConfigPath(path)
+	t.Cleanup(func() { SetConfigPath("") })
+	return path
+}
+
+func TestLoadConfigTargetWithOverridesWritesNoFile(t *testing.T) {
+	clearOverrides(t)
+	path := useTestConfigPath(t)
+	t.Setenv("GMAM_GMOD_DIR", "/env/gmod")
+
+	cfg, err := LoadConfigTarget("", Overrides{"workers": "3"})
+	if err != nil {
+		t.Fatalf("LoadConfigTarget: %v", err)
+	}
+	if cfg.GModDir != "/env/gmod" || cfg.Workers != 3 {
+		t.Errorf("config = %+v, want the overridden values", cfg)
+	}
+	if cfg.AddonDir != filepath.Join("/env/gmod", "garrysmod", "addons") {
+		t.Errorf("addon_dir = %q, want it derived from the overridden gmod_dir", cfg.AddonDir)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("a config file was written for an overridden run: %v", err)
+	}
+}
+
+func TestLoadConfigTargetWritesDefaults(t *testing.T) {
+	clearOverrides(t)
+	path := useTestConfigPath(t)
+
+	if _, err := LoadConfigTarget("", nil); err != nil {
+		t.Fatalf("LoadConfigTarget: %v", err)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("default config wasn't written: %v", err)
+	}
+}
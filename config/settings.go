@@ -0,0 +1,243 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Setting is a config key that can be read and changed by name
+type Setting struct {
+	Key    string // JSON key, e.g. "gmod_dir"
+	Label  string
+	Secret bool // masked unless asked for
+
+	// Wizard marks the settings config init walks through
+	Wizard bool
+
+	get func(*Config) string
+	set func(*Config, string) error
+}
+
+func stringSetting(key, label string, field func(*Config) *string) Setting {
+	return Setting{
+		Key:   key,
+		Label: label,
+		get:   func(c *Config) string { return *field(c) },
+		set: func(c *Config, value string) error {
+			*field(c) = value
+			return nil
+		},
+	}
+}
+
+func wizard(s Setting) Setting {
+	s.Wizard = true
+	return s
+}
+
+func secret(s Setting) Setting {
+	s.Secret = true
+	return s
+}
+
+// Settings lists every config key in the order they are shown
+var Settings = []Setting{
+	wizard(stringSetting("gmod_dir", "GMod Directory", func(c *Config) *string { return &c.GModDir })),
+	wizard(stringSetting("download_dir", "Download Directory", func(c *Config) *string { return &c.DownloadDir })),
+	stringSetting("addon_dir", "Addon Directory", func(c *Config) *string { return &c.AddonDir }),
+	stringSetting("out_dir", "Output Directory", func(c *Config) *string { return &c.OutDir }),
+	stringSetting("tmp_dir", "Temp Directory", func(c *Config) *string { return &c.TmpDir }),
+	wizard(stringSetting("steamcmd_path", "SteamCMD Path", func(c *Config) *string { return &c.SteamCmdPath })),
+	wizard(stringSetting("gmad_path", "GMAD Path", func(c *Config) *string { return &c.GMADPath })),
+	wizard(Setting{
+		Key:   "downloaders",
+		Label: "Downloaders",
+		get:   func(c *Config) string { return strings.Join(c.Downloaders, ",") },
+		set: func(c *Config, value string) error {
+			c.Downloaders = nil
+			for _, name := range strings.Split(value, ",") {
+				if name = strings.TrimSpace(name); name != "" {
+					c.Downloaders = append(c.Downloaders, name)
+				}
+			}
+			return nil
+		},
+	}),
+	stringSetting("mirror_dir", "Mirror Directory", func(c *Config) *string { return &c.MirrorDir }),
+	wizard(Setting{
+		Key:   "workers",
+		Label: "Workers",
+		get: func(c *Config) string {
+			if c.Workers == 0 {
+				return ""
+			}
+			return strconv.Itoa(c.Workers)
+		},
+		set: func(c *Config, value string) error {
+			if value == "" {
+				c.Workers = 0
+				return nil
+			}
+			workers, err := strconv.Atoi(value)
+			if err != nil || workers < 1 {
+				return fmt.Errorf("workers must be a number of at least 1")
+			}
+			c.Workers = workers
+			return nil
+		},
+	}),
+	{
+		Key:   "cache_ttl",
+		Label: "Cache TTL",
+		get:   func(c *Config) string { return c.CacheTTL },
+		set: func(c *Config, value string) error {
+			if value != "" {
+				if _, err := parseCacheTTL(value); err != nil {
+					return err
+				}
+			}
+			c.CacheTTL = value
+			return nil
+		},
+	},
+	wizard(secret(stringSetting("steam_api_key", "Steam API Key", func(c *Config) *string { return &c.SteamAPIKey }))),
+}
+
+// LookupSetting finds a setting by its JSON key
+func LookupSetting(key string) (Setting, error) {
+	for _, s := range Settings {
+		if s.Key == key {
+			return s, nil
+		}
+	}
+
+	keys := make([]string, 0, len(Settings))
+	for _, s := range Settings {
+		keys = append(keys, s.Key)
+	}
+	return Setting{}, fmt.Errorf("unknown config key %q (one of %s)", key, strings.Join(keys, ", "))
+}
+
+// Get returns the value of the setting in cfg
+func (s Setting) Get(cfg *Config) string {
+	return s.get(cfg)
+}
+
+// Set parses value into the setting in cfg. An empty value unsets it.
+func (s Setting) Set(cfg *Config, value string) error {
+	return s.set(cfg, strings.TrimSpace(value))
+}
+
+// Display returns the value for printing, masking secrets unless reveal is set
+func (s Setting) Display(cfg *Config, reveal bool) string {
+	value := s.get(cfg)
+	if s.Secret && !reveal {
+		return Mask(value)
+	}
+	return value
+}
+
+// Mask hides a secret, keeping the last few characters so keys can be told apart
+func Mask(value string) string {
+	switch {
+	case value == "":
+		return ""
+	case len(value) <= 8:
+		return strings.Repeat("*", len(value))
+	default:
+		return strings.Repeat("*", len(value)-4) + value[len(value)-4:]
+	}
+}
+
+// LoadConfigFile reads the config file as written, without filling in
+// defaults, so it can be changed and saved again. A missing file gives an
+// empty config.
+func LoadConfigFile() (*Config, error) {
+	configPath, err := getConfigPath()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get config path: %w", err)
+	}
+
+	var config Config
+	data, err := os.ReadFile(configPath)
+	if os.IsNotExist(err) {
+		return &config, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse config file: %w", err)
+	}
+	return &config, nil
+}
+
+// WithDefaults returns a copy of the config with empty settings filled in
+// the way LoadConfig does
+func (c *Config) WithDefaults() *Config {
+	filled := *c
+	filled.Downloaders = append([]string(nil), c.Downloaders...)
+	return fillInDefaultPaths(&filled)
+}
+
+// ValidationError is returned when a config is not saved because a change
+// introduced errors
+type ValidationError struct {
+	Problems []Problem // only the new errors
+}
+
+func (e *ValidationError) Error() string {
+	errs := make([]string, 0, len(e.Problems))
+	for _, p := range e.Problems {
+		errs = append(errs, p.String())
+	}
+	return "invalid config: " + strings.Join(errs, "; ")
+}
+
+// validateTargets validates every target of the config with its defaults
+// filled in. Shared settings are only reported for the default target.
+func validateTargets(config *Config) []Problem {
+	var problems []Problem
+	for _, name := range config.TargetNames() {
+		selected, err := config.ForTarget(name)
+		if err != nil {
+			continue
+		}
+		for _, p := range Validate(selected.WithDefaults()) {
+			if name == DefaultTargetName || isTargetKey(p.Key) {
+				problems = append(problems, p)
+			}
+		}
+	}
+	return problems
+}
+
+// ValidateAndSave validates every target of the config with its defaults
+// filled in and saves it as is, unless it has errors the saved config
+// didn't have. Errors that were already there don't block saving, so a
+// broken config can be fixed one key at a time. Every problem found,
+// warnings included, is returned.
+func ValidateAndSave(config *Config) ([]Problem, error) {
+	problems := validateTargets(config)
+
+	known := make(map[string]bool)
+	if saved, err := LoadConfigFile(); err == nil {
+		for _, p := range validateTargets(saved) {
+			known[p.String()] = true
+		}
+	}
+
+	var introduced []Problem
+	for _, p := range problems {
+		if p.Severity == SeverityError && !known[p.String()] {
+			introduced = append(introduced, p)
+		}
+	}
+	if len(introduced) > 0 {
+		return problems, &ValidationError{Problems: introduced}
+	}
+	return problems, SaveConfig(config)
+}
@@ -0,0 +1,150 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+)
+
+// DefaultTargetName is the target described by the top-level paths
+const DefaultTargetName = "default"
+
+// TargetEnv selects a target when --target isn't given
+const TargetEnv = "GMAM_TARGET"
+
+// ErrUnknownTarget is returned for a target name that isn't in the config
+var ErrUnknownTarget = errors.New("unknown target")
+
+// Target is another Garry's Mod install managed from the same config, like
+// a dedicated server. Empty paths are derived from its GModDir the same way
+// the top-level ones are.
+type Target struct {
+	GModDir  string `json:"gmod_dir"`
+	AddonDir string `json:"addon_dir,omitempty"`
+	OutDir   string `json:"out_dir,omitempty"`
+	TmpDir   string `json:"tmp_dir,omitempty"`
+	GMADPath string `json:"gmad_path,omitempty"`
+}
+
+// targetOf returns the install paths of a config as a target
+func targetOf(c *Config) Target {
+	return Target{
+		GModDir:  c.GModDir,
+		AddonDir: c.AddonDir,
+		OutDir:   c.OutDir,
+		TmpDir:   c.TmpDir,
+		GMADPath: c.GMADPath,
+	}
+}
+
+// isTargetKey reports whether a setting belongs to a target rather than
+// being shared by all of them
+func isTargetKey(key string) bool {
+	switch key {
+	case "gmod_dir", "addon_dir", "out_dir", "tmp_dir", "gmad_path":
+		return true
+	}
+	return false
+}
+
+// apply replaces the install paths of a config with the target's
+func (t Target) apply(c *Config) {
+	c.GModDir = t.GModDir
+	c.AddonDir = t.AddonDir
+	c.OutDir = t.OutDir
+	c.TmpDir = t.TmpDir
+	c.GMADPath = t.GMADPath
+}
+
+// TargetNames lists the default target followed by the named ones, sorted
+func (c *Config) TargetNames() []string {
+	names := make([]string, 0, len(c.Targets)+1)
+	for name := range c.Targets {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return append([]string{DefaultTargetName}, names...)
+}
+
+// ResolveTarget picks the target to use: the given name, then GMAM_TARGET,
+// then default_target from the config file
+func (c *Config) ResolveTarget(name string) string {
+	for _, candidate := range []string{name, os.Getenv(TargetEnv), c.DefaultTarget} {
+		if candidate != "" {
+			return candidate
+		}
+	}
+	return DefaultTargetName
+}
+
+// ForTarget returns a copy of the config with the install paths of the
+// named target in place of the top-level ones. Defaults aren't filled in.
+func (c *Config) ForTarget(name string) (*Config, error) {
+	selected := *c
+	selected.Target = DefaultTargetName
+	if name == "" || name == DefaultTargetName {
+		return &selected, nil
+	}
+
+	target, ok := c.Targets[name]
+	if !ok {
+		return nil, fmt.Errorf("%w %q (one of %s)", ErrUnknownTarget, name, strings.Join(c.TargetNames(), ", "))
+	}
+	target.apply(&selected)
+	selected.Target = name
+	return &selected, nil
+}
+
+// EditTarget changes the settings of the named target. Install paths go to
+// the target, every other setting is shared and goes to the top level.
+func (c *Config) EditTarget(name string, edit func(*Config) error) error {
+	selected, err := c.ForTarget(name)
+	if err != nil {
+		return err
+	}
+	if err := edit(selected); err != nil {
+		return err
+	}
+
+	if selected.Target == DefaultTargetName {
+		*c = *selected
+		return nil
+	}
+	targets := c.Targets
+	topLevel := targetOf(c)
+	*c = *selected
+	topLevel.apply(c)
+	targets[name] = targetOf(selected)
+	c.Targets = targets
+	return nil
+}
+
+// AddTarget adds a named install, or replaces the one with that name
+func (c *Config) AddTarget(name string, target Target) error {
+	if name == "" || name == DefaultTargetName || strings.ContainsAny(name, " \t") {
+		return fmt.Errorf("invalid target name %q", name)
+	}
+	if target.GModDir == "" {
+		return fmt.Errorf("target %s needs a gmod_dir", name)
+	}
+	if c.Targets == nil {
+		c.Targets = make(map[string]Target)
+	}
+	c.Targets[name] = target
+	return nil
+}
+
+// RemoveTarget removes a named install, and makes the default target the
+// default again if it was
+func (c *Config) RemoveTarget(name string) error {
+	if _, ok := c.Targets[name]; !ok {
+		return fmt.Errorf("%w %q", ErrUnknownTarget, name)
+	}
+	delete(c.Targets, name)
+	if c.DefaultTarget == name {
+		c.DefaultTarget = ""
+	}
+	return nil
+}
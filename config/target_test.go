@@ -0,0 +1,116 @@
+package config
+
+import (
+	"errors"
+	"testing"
+)
+
+func newTargetConfig() *Config {
+	return &Config{
+		GModDir:  "/games/gmod",
+		AddonDir: "/games/gmod/garrysmod/addons",
+		Workers:  2,
+		Targets: map[string]Target{
+			"server": {GModDir: "/srv/gmod"},
+		},
+	}
+}
+
+func TestForTarget(t *testing.T) {
+	c := newTargetConfig()
+
+	for _, name := range []string{"", DefaultTargetName} {
+		selected, err := c.ForTarget(name)
+		if err != nil {
+			t.Fatalf("ForTarget(%q): %v", name, err)
+		}
+		if selected.Target != DefaultTargetName || selected.GModDir != "/games/gmod" || selected.AddonDir != c.AddonDir {
+			t.Errorf("ForTarget(%q) = %+v, want the top-level paths", name, selected)
+		}
+	}
+
+	// A named target replaces every install path, leaving the shared settings
+	selected, err := c.ForTarget("server")
+	if err != nil {
+		t.Fatalf("ForTarget(server): %v", err)
+	}
+	if selected.Target != "server" || selected.GModDir != "/srv/gmod" || selected.AddonDir != "" || selected.Workers != 2 {
+		t.Errorf("ForTarget(server) = %+v", selected)
+	}
+	if c.GModDir != "/games/gmod" {
+		t.Errorf("ForTarget changed the config it was called on: %+v", c)
+	}
+
+	if _, err := c.ForTarget("missing"); !errors.Is(err, ErrUnknownTarget) {
+		t.Errorf("ForTarget(missing) error = %v, want ErrUnknownTarget", err)
+	}
+}
+
+func TestResolveTarget(t *testing.T) {
+	c := newTargetConfig()
+	if got := c.ResolveTarget(""); got != DefaultTargetName {
+		t.Errorf("ResolveTarget with nothing set = %q, want %q", got, DefaultTargetName)
+	}
+
+	c.DefaultTarget = "server"
+	if got := c.ResolveTarget(""); got != "server" {
+		t.Errorf("ResolveTarget from default_target = %q, want server", got)
+	}
+
+	t.Setenv(TargetEnv, "env")
+	if got := c.ResolveTarget(""); got != "env" {
+		t.Errorf("ResolveTarget from %s = %q, want env", TargetEnv, got)
+	}
+	if got := c.ResolveTarget("flag"); got != "flag" {
+		t.Errorf("ResolveTarget with a name = %q, want flag", got)
+	}
+}
+
+func TestEditTarget(t *testing.T) {
+	c := newTargetConfig()
+
+	// Install paths go to the target, everything else to the top level
+	err := c.EditTarget("server", func(selected *Config) error {
+		selected.AddonDir = "/srv/addons"
+		selected.Workers = 8
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("EditTarget(server): %v", err)
+	}
+	if got := c.Targets["server"]; got.AddonDir != "/srv/addons" || got.GModDir != "/srv/gmod" {
+		t.Errorf("server target = %+v", got)
+	}
+	if c.AddonDir != "/games/gmod/garrysmod/addons" || c.GModDir != "/games/gmod" {
+		t.Errorf("top-level paths changed: %+v", c)
+	}
+	if c.Workers != 8 {
+		t.Errorf("workers = %d, want 8", c.Workers)
+	}
+
+	if err := c.EditTarget(DefaultTargetName, func(selected *Config) error {
+		selected.GModDir = "/games/other"
+		return nil
+	}); err != nil {
+		t.Fatalf("EditTarget(default): %v", err)
+	}
+	if c.GModDir != "/games/other" || c.Targets["server"].GModDir != "/srv/gmod" {
+		t.Errorf("after editing the default target: %+v", c)
+	}
+
+	// A failing edit leaves the config alone
+	failed := errors.New("invalid")
+	if err := c.EditTarget("server", func(selected *Config) error {
+		selected.GModDir = "/broken"
+		return failed
+	}); err != failed {
+		t.Errorf("EditTarget error = %v, want %v", err, failed)
+	}
+	if c.Targets["server"].GModDir != "/srv/gmod" {
+		t.Errorf("failed edit was applied: %+v", c.Targets["server"])
+	}
+
+	if err := c.EditTarget("missing", func(*Config) error { return nil }); !errors.Is(err, ErrUnknownTarget) {
+		t.Errorf("EditTarget(missing) error = %v, want ErrUnknownTarget", err)
+	}
+}
@@ -0,0 +1,244 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"slices"
+
+	"gmod-addon-manager/steam"
+)
+
+// Severity tells whether a problem stops the manager from working
+type Severity int
+
+const (
+	SeverityError   Severity = iota // installs will fail
+	SeverityWarning                 // works, but probably not as intended
+)
+
+func (s Severity) String() string {
+	switch s {
+	case SeverityError:
+		return "error"
+	case SeverityWarning:
+		return "warning"
+	default:
+		return "unknown"
+	}
+}
+
+// Problem is something wrong with a config setting and how to fix it
+type Problem struct {
+	Target   string // name of the target the problem is in
+	Key      string // JSON key of the setting, e.g. "gmod_dir"
+	Severity Severity
+	Message  string
+	Fix      string
+}
+
+func (p Problem) String() string {
+	if p.Target != "" && p.Target != DefaultTargetName {
+		return fmt.Sprintf("%s (target %s): %s", p.Key, p.Target, p.Message)
+	}
+	return fmt.Sprintf("%s: %s", p.Key, p.Message)
+}
+
+// HasErrors reports whether any of the problems is an error
+func HasErrors(problems []Problem) bool {
+	for _, p := range problems {
+		if p.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate checks the config against the machine it runs on: that the
+// directories exist and are usable, the tools can be run and steamcmd
+// downloads where the manager looks. Paths are expected to be filled in,
+// as LoadConfig does.
+func Validate(cfg *Config) []Problem {
+	var problems []Problem
+	add := func(key string, severity Severity, message, fix string) {
+		problems = append(problems, Problem{Key: key, Severity: severity, Message: message, Fix: fix})
+	}
+
+	// Detection is only needed to suggest fixes, so do it once on demand
+	var detected *Detected
+	detect := func() Detected {
+		if detected == nil {
+			d := Detect()
+			detected = &d
+		}
+		return *detected
+	}
+
+	if !isDir(filepath.Join(cfg.GModDir, "garrysmod")) {
+		fix := "set gmod_dir to the folder that contains the garrysmod directory"
+		if dir := detect().GModDir; dir != "" && dir != cfg.GModDir {
+			fix = fmt.Sprintf("set gmod_dir to %s, where Garry's Mod was found", dir)
+		}
+		add("gmod_dir", SeverityError, fmt.Sprintf("%s is not a Garry's Mod install", cfg.GModDir), fix)
+	}
+
+	for _, dir := range []struct{ key, path string }{
+		{"addon_dir", cfg.AddonDir},
+		{"out_dir", cfg.OutDir},
+	} {
+		if err := checkWritable(dir.path); err != nil {
+			add(dir.key, SeverityError, err.Error(), fmt.Sprintf("make %s writable by your user, or point %s somewhere else", dir.path, dir.key))
+			continue
+		}
+		if err := checkSymlinks(dir.path); err != nil {
+			fix := fmt.Sprintf("move %s to a filesystem that supports symlinks (not FAT32 or exFAT)", dir.key)
+			if runtime.GOOS == "windows" {
+				fix = "enable Developer Mode in the Windows settings, which allows creating symlinks without admin rights"
+			}
+			add(dir.key, SeverityError, err.Error(), fix)
+		}
+	}
+
+	// gmad is optional, the built-in extractor is used when it is missing
+	if cfg.GMADPath != "" {
+		info, err := os.Stat(cfg.GMADPath)
+		switch {
+		case err != nil:
+			fix := "nothing to do unless you want gmad, the built-in extractor handles every addon"
+			if path := steam.FindGMAD(cfg.GModDir); path != "" && path != cfg.GMADPath {
+				fix = fmt.Sprintf("set gmad_path to %s", path)
+			}
+			add("gmad_path", SeverityWarning, fmt.Sprintf("%s was not found, the built-in extractor will be used", cfg.GMADPath), fix)
+		case !isExecutable(info):
+			add("gmad_path", SeverityError, fmt.Sprintf("%s is not executable", cfg.GMADPath), fmt.Sprintf("run chmod +x %s", cfg.GMADPath))
+		}
+	}
+
+	if slices.Contains(cfg.Downloaders, "steamcmd") {
+		problems = append(problems, validateSteamCmd(cfg, detect)...)
+	}
+
+	for _, name := range cfg.Downloaders {
+		switch name {
+		case "steamcmd", "http":
+		case "local":
+			if cfg.MirrorDir == "" {
+				add("mirror_dir", SeverityError, "the local downloader requires mirror_dir", "set mirror_dir to the folder holding <id>.gma files, or remove local from downloaders")
+			} else if !isDir(cfg.MirrorDir) {
+				add("mirror_dir", SeverityError, fmt.Sprintf("%s does not exist", cfg.MirrorDir), "create it, or set mirror_dir to the folder holding <id>.gma files")
+			}
+		default:
+			add("downloaders", SeverityError, fmt.Sprintf("unknown downloader %q", name), "use steamcmd, http or local")
+		}
+	}
+
+	if _, err := parseCacheTTL(cfg.CacheTTL); err != nil {
+		add("cache_ttl", SeverityError, fmt.Sprintf("%q is not a valid duration", cfg.CacheTTL), "set cache_ttl to a duration like 24h or 30m")
+	}
+
+	for i := range problems {
+		if isTargetKey(problems[i].Key) {
+			problems[i].Target = cfg.Target
+		}
+	}
+	return problems
+}
+
+func validateSteamCmd(cfg *Config, detect func() Detected) []Problem {
+	path, err := exec.LookPath(cfg.SteamCmdPath)
+	if err != nil {
+		fix := "install steamcmd, or set steamcmd_path to the steamcmd binary"
+		if found := detect().SteamCmdPath; found != "" && found != cfg.SteamCmdPath {
+			fix = fmt.Sprintf("set steamcmd_path to %s", found)
+		}
+		message := fmt.Sprintf("%s can't be run", cfg.SteamCmdPath)
+		if errors.Is(err, exec.ErrNotFound) || errors.Is(err, os.ErrNotExist) {
+			message = fmt.Sprintf("%s was not found", cfg.SteamCmdPath)
+		}
+		return []Problem{{Key: "steamcmd_path", Severity: SeverityError, Message: message, Fix: fix}}
+	}
+
+	// steamcmd reports where it put each item, but the manager falls back to
+	// DownloadDir when the path is missing from its output
+	downloadDir := steam.SteamCmdAt(path).DownloadDir()
+	if !samePath(downloadDir, cfg.DownloadDir) {
+		return []Problem{{
+			Key:      "download_dir",
+			Severity: SeverityWarning,
+			Message:  fmt.Sprintf("%s is not where %s downloads to", cfg.DownloadDir, cfg.SteamCmdPath),
+			Fix:      fmt.Sprintf("set download_dir to %s", downloadDir),
+		}}
+	}
+	return nil
+}
+
+// checkWritable creates and removes a file in dir. Directories the manager
+// creates itself are checked through their closest existing parent.
+func checkWritable(dir string) error {
+	for !isDir(dir) {
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return fmt.Errorf("%s does not exist", dir)
+		}
+		dir = parent
+	}
+
+	f, err := os.CreateTemp(dir, ".gmod-addon-manager-*")
+	if err != nil {
+		return fmt.Errorf("%s is not writable: %w", dir, errors.Unwrap(err))
+	}
+	f.Close()
+	return os.Remove(f.Name())
+}
+
+// checkSymlinks creates and removes a symlink in dir, or its closest
+// existing parent
+func checkSymlinks(dir string) error {
+	for !isDir(dir) {
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return fmt.Errorf("%s does not exist", dir)
+		}
+		dir = parent
+	}
+
+	target, err := os.MkdirTemp(dir, ".gmod-addon-manager-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(target)
+
+	link := target + "-link"
+	if err := os.Symlink(target, link); err != nil {
+		return fmt.Errorf("can't create symlinks in %s: %w", dir, errors.Unwrap(err))
+	}
+	return os.Remove(link)
+}
+
+func isDir(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
+
+func isExecutable(info os.FileInfo) bool {
+	if info.IsDir() {
+		return false
+	}
+	// Windows has no executable bit, the extension decides
+	return runtime.GOOS == "windows" || info.Mode()&0111 != 0
+}
+
+// samePath compares paths after resolving symlinks, like ~/.steam/steam
+// pointing into ~/.local/share/Steam
+func samePath(a, b string) bool {
+	resolve := func(path string) string {
+		if resolved, err := filepath.EvalSymlinks(path); err == nil {
+			return resolved
+		}
+		return filepath.Clean(path)
+	}
+	return resolve(a) == resolve(b)
+}
@@ -0,0 +1,92 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+// validConfig returns a config Validate has nothing to complain about
+func validConfig(t *testing.T) *Config {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+	gmodDir := t.TempDir()
+	cfg := &Config{
+		GModDir:     gmodDir,
+		AddonDir:    filepath.Join(gmodDir, "garrysmod", "addons"),
+		OutDir:      filepath.Join(gmodDir, "garrysmod", "addons-managed"),
+		Downloaders: []string{"http"},
+		CacheTTL:    DefaultCacheTTL,
+	}
+	if err := os.MkdirAll(cfg.AddonDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	return cfg
+}
+
+func TestValidate(t *testing.T) {
+	tests := map[string]struct {
+		modify   func(t *testing.T, cfg *Config)
+		key      string
+		severity Severity
+	}{
+		"valid": {func(t *testing.T, cfg *Config) {}, "", 0},
+		"not a gmod install": {func(t *testing.T, cfg *Config) {
+			cfg.GModDir = t.TempDir()
+		}, "gmod_dir", SeverityError},
+		"unknown downloader": {func(t *testing.T, cfg *Config) {
+			cfg.Downloaders = []string{"ftp"}
+		}, "downloaders", SeverityError},
+		"local without mirror": {func(t *testing.T, cfg *Config) {
+			cfg.Downloaders = []string{"local"}
+		}, "mirror_dir", SeverityError},
+		"missing mirror": {func(t *testing.T, cfg *Config) {
+			cfg.Downloaders = []string{"local"}
+			cfg.MirrorDir = filepath.Join(t.TempDir(), "missing")
+		}, "mirror_dir", SeverityError},
+		"missing gmad": {func(t *testing.T, cfg *Config) {
+			cfg.GMADPath = filepath.Join(t.TempDir(), "gmad")
+		}, "gmad_path", SeverityWarning},
+		"missing steamcmd": {func(t *testing.T, cfg *Config) {
+			cfg.Downloaders = []string{"steamcmd"}
+			cfg.SteamCmdPath = filepath.Join(t.TempDir(), "steamcmd")
+		}, "steamcmd_path", SeverityError},
+		"invalid cache ttl": {func(t *testing.T, cfg *Config) {
+			cfg.CacheTTL = "soon"
+		}, "cache_ttl", SeverityError},
+		"gmad not executable": {func(t *testing.T, cfg *Config) {
+			if runtime.GOOS == "windows" {
+				t.Skip("windows has no executable bit")
+			}
+			cfg.GMADPath = filepath.Join(t.TempDir(), "gmad")
+			if err := os.WriteFile(cfg.GMADPath, nil, 0644); err != nil {
+				t.Fatal(err)
+			}
+		}, "gmad_path", SeverityError},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			cfg := validConfig(t)
+			tt.modify(t, cfg)
+
+			problems := Validate(cfg)
+			if tt.key == "" {
+				if len(problems) != 0 {
+					t.Errorf("Validate() = %v, want no problems", problems)
+				}
+				return
+			}
+			if len(problems) != 1 || problems[0].Key != tt.key || problems[0].Severity != tt.severity {
+				t.Fatalf("Validate() = %v, want one %s for %s", problems, tt.severity, tt.key)
+			}
+			if problems[0].Fix == "" {
+				t.Errorf("%s has no fix", problems[0])
+			}
+			if got := HasErrors(problems); got != (tt.severity == SeverityError) {
+				t.Errorf("HasErrors() = %v", got)
+			}
+		})
+	}
+}
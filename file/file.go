@@ -56,16 +56,22 @@ func Copy(src, dst string) error {
 }
 
 func ExtractLZMA(src, dst string) error {
+	return ExtractLZMAProgress(src, dst, nil)
+}
+
+// ExtractLZMAProgress is ExtractLZMA reporting progress through the
+// compressed input
+func ExtractLZMAProgress(src, dst string, fn ProgressFunc) error {
 	f, err := os.Open(src)
 	if err != nil {
 		return err
 	}
-	// no need for defer; Fatal calls os.Exit(1) that doesn't execute deferred functions
-	r, err := lzma.NewReader(bufio.NewReader(f))
+	defer f.Close()
+	stat, err := f.Stat()
 	if err != nil {
 		return err
 	}
-	stat, err := f.Stat()
+	r, err := lzma.NewReader(bufio.NewReader(NewProgressReader(f, stat.Size(), fn)))
 	if err != nil {
 		return err
 	}
@@ -73,14 +79,12 @@ func ExtractLZMA(src, dst string) error {
 	if err != nil {
 		return err
 	}
+	defer outFile.Close()
 	_, err = io.Copy(outFile, r)
 	if err != nil {
 		return err
 	}
-	if err := f.Close(); err != nil {
-		return err
-	}
-	return nil
+	return outFile.Close()
 }
 
 // extracts a zip archive to a destination directory.
@@ -130,3 +134,17 @@ func ExtractZip(src, dest string) error {
 	}
 	return nil
 }
+
+// FormatSize formats a byte count using binary units
+func FormatSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(size)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,278 @@
+package file
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// GMAIdent is the magic number at the start of every GMA archive
+const GMAIdent = "GMAD"
+
+// GMAVersion is the archive format version written by gmad
+const GMAVersion = 3
+
+// GMAEntry describes a single file stored in a GMA archive
+type GMAEntry struct {
+	Name   string
+	Size   int64
+	CRC    uint32
+	Offset int64 // offset relative to the start of the file data block
+}
+
+// GMAMetadata is the JSON document gmad stores in the description field.
+// It mirrors the layout of addon.json.
+type GMAMetadata struct {
+	Title       string   `json:"title,omitempty"`
+	Description string   `json:"description"`
+	Type        string   `json:"type"`
+	Tags        []string `json:"tags"`
+	Ignore      []string `json:"ignore,omitempty"`
+}
+
+// GMA holds the parsed header and file table of a GMA archive
+type GMA struct {
+	Version      byte
+	SteamID      uint64
+	Timestamp    uint64
+	Required     []string
+	Name         string
+	Description  string
+	Author       string
+	AddonVersion int32
+	Files        []GMAEntry
+
+	// DataOffset is the absolute offset of the first file's contents
+	DataOffset int64
+}
+
+// Metadata parses the description field as gmad's JSON metadata.
+// Archives built by other tools may store plain text, in which case
+// the raw text is returned as the description. The title is always the
+// archive's name.
+func (g *GMA) Metadata() GMAMetadata {
+	var meta GMAMetadata
+	if err := json.Unmarshal([]byte(g.Description), &meta); err != nil {
+		return GMAMetadata{Title: g.Name, Description: g.Description}
+	}
+	meta.Title = g.Name
+	return meta
+}
+
+// Size returns the total size of all files in the archive
+func (g *GMA) Size() int64 {
+	var total int64
+	for _, entry := range g.Files {
+		total += entry.Size
+	}
+	return total
+}
+
+// gmaReader reads the little-endian primitives used by the GMA format
+// while keeping track of how many bytes have been consumed.
+type gmaReader struct {
+	r   *bufio.Reader
+	pos int64
+}
+
+func (r *gmaReader) read(v any) error {
+	if err := binary.Read(r.r, binary.LittleEndian, v); err != nil {
+		return err
+	}
+	r.pos += int64(binary.Size(v))
+	return nil
+}
+
+func (r *gmaReader) readString() (string, error) {
+	s, err := r.r.ReadString(0)
+	if err != nil {
+		return "", err
+	}
+	r.pos += int64(len(s))
+	return strings.TrimSuffix(s, "\x00"), nil
+}
+
+// ReadGMA parses the header and file table of a GMA archive.
+// The reader is buffered internally, so use OpenGMA when the file
+// contents are needed as well.
+func ReadGMA(r io.Reader) (*GMA, error) {
+	return readGMA(&gmaReader{r: bufio.NewReader(r)})
+}
+
+func readGMA(r *gmaReader) (*GMA, error) {
+	ident := make([]byte, len(GMAIdent))
+	if _, err := io.ReadFull(r.r, ident); err != nil {
+		return nil, fmt.Errorf("failed to read ident: %w", err)
+	}
+	r.pos += int64(len(ident))
+	if string(ident) != GMAIdent {
+		return nil, fmt.Errorf("not a gma file: bad ident %q", ident)
+	}
+
+	gma := &GMA{}
+	if err := r.read(&gma.Version); err != nil {
+		return nil, fmt.Errorf("failed to read version: %w", err)
+	}
+	if gma.Version > GMAVersion {
+		return nil, fmt.Errorf("unsupported gma version %d", gma.Version)
+	}
+
+	if err := r.read(&gma.SteamID); err != nil {
+		return nil, fmt.Errorf("failed to read steamid: %w", err)
+	}
+	if err := r.read(&gma.Timestamp); err != nil {
+		return nil, fmt.Errorf("failed to read timestamp: %w", err)
+	}
+
+	// Required content list (unused by the game, terminated by an empty string)
+	if gma.Version > 1 {
+		for {
+			s, err := r.readString()
+			if err != nil {
+				return nil, fmt.Errorf("failed to read required content: %w", err)
+			}
+			if s == "" {
+				break
+			}
+			gma.Required = append(gma.Required, s)
+		}
+	}
+
+	var err error
+	if gma.Name, err = r.readString(); err != nil {
+		return nil, fmt.Errorf("failed to read name: %w", err)
+	}
+	if gma.Description, err = r.readString(); err != nil {
+		return nil, fmt.Errorf("failed to read description: %w", err)
+	}
+	if gma.Author, err = r.readString(); err != nil {
+		return nil, fmt.Errorf("failed to read author: %w", err)
+	}
+	if err := r.read(&gma.AddonVersion); err != nil {
+		return nil, fmt.Errorf("failed to read addon version: %w", err)
+	}
+
+	// File table, terminated by a zero file number
+	var offset int64
+	for {
+		var fileNum uint32
+		if err := r.read(&fileNum); err != nil {
+			return nil, fmt.Errorf("failed to read file table: %w", err)
+		}
+		if fileNum == 0 {
+			break
+		}
+
+		var entry GMAEntry
+		if entry.Name, err = r.readString(); err != nil {
+			return nil, fmt.Errorf("failed to read file name: %w", err)
+		}
+		if err := r.read(&entry.Size); err != nil {
+			return nil, fmt.Errorf("failed to read size of %s: %w", entry.Name, err)
+		}
+		if err := r.read(&entry.CRC); err != nil {
+			return nil, fmt.Errorf("failed to read crc of %s: %w", entry.Name, err)
+		}
+		if entry.Size < 0 {
+			return nil, fmt.Errorf("invalid size %d for %s", entry.Size, entry.Name)
+		}
+		entry.Offset = offset
+		offset += entry.Size
+		gma.Files = append(gma.Files, entry)
+	}
+
+	gma.DataOffset = r.pos
+	return gma, nil
+}
+
+// GMAFile is an open GMA archive whose contents can be streamed
+type GMAFile struct {
+	*GMA
+	f *os.File
+}
+
+// OpenGMA opens a GMA archive and parses its header
+func OpenGMA(path string) (*GMAFile, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open gma file: %w", err)
+	}
+
+	gma, err := ReadGMA(f)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	return &GMAFile{GMA: gma, f: f}, nil
+}
+
+// Open returns a reader for the contents of a single entry
+func (g *GMAFile) Open(entry GMAEntry) io.Reader {
+	return io.NewSectionReader(g.f, g.DataOffset+entry.Offset, entry.Size)
+}
+
+func (g *GMAFile) Close() error {
+	return g.f.Close()
+}
+
+// ExtractGMA extracts every file in a GMA archive into dest
+func ExtractGMA(src, dest string) error {
+	return ExtractGMAProgress(src, dest, nil)
+}
+
+// ExtractGMAProgress is ExtractGMA reporting the file data extracted so far
+func ExtractGMAProgress(src, dest string, fn ProgressFunc) error {
+	gma, err := OpenGMA(src)
+	if err != nil {
+		return err
+	}
+	defer gma.Close()
+
+	var done int64
+	total := gma.Size()
+	for _, entry := range gma.Files {
+		// Prevent entries from writing outside the dest directory
+		filePath := filepath.Join(dest, filepath.FromSlash(entry.Name))
+		if !strings.HasPrefix(filePath, filepath.Clean(dest)+string(os.PathSeparator)) {
+			return fmt.Errorf("invalid file path: %s", entry.Name)
+		}
+
+		if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+			return fmt.Errorf("failed to create directory for %s: %w", entry.Name, err)
+		}
+
+		if err := extractGMAEntry(gma, entry, filePath); err != nil {
+			return err
+		}
+		if fn != nil {
+			done += entry.Size
+			fn(done, total)
+		}
+	}
+
+	return nil
+}
+
+func extractGMAEntry(gma *GMAFile, entry GMAEntry, filePath string) error {
+	outFile, err := os.Create(filePath)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", entry.Name, err)
+	}
+	defer outFile.Close()
+
+	n, err := io.Copy(outFile, gma.Open(entry))
+	if err != nil {
+		return fmt.Errorf("failed to extract %s: %w", entry.Name, err)
+	}
+	if n != entry.Size {
+		return fmt.Errorf("failed to extract %s: archive is truncated", entry.Name)
+	}
+
+	return nil
+}
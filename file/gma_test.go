@@ -0,0 +1,320 @@
+package file
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type testEntry struct {
+	name string
+	data string
+}
+
+// buildGMA assembles a version 3 archive by hand, the way gmad lays it out
+func buildGMA(entries []testEntry) []byte {
+	var b bytes.Buffer
+	le := func(v any) { binary.Write(&b, binary.LittleEndian, v) }
+	str := func(s string) { b.WriteString(s + "\x00") }
+
+	b.WriteString(GMAIdent)
+	le(byte(GMAVersion))
+	le(uint64(76561197960287930))
+	le(uint64(1700000000))
+	str("") // required content
+	str("Test Addon")
+	str(`{"description":"desc","type":"tool","tags":["fun","build"]}`)
+	str("Author Name")
+	le(int32(1))
+	for i, e := range entries {
+		le(uint32(i + 1))
+		str(e.name)
+		le(int64(len(e.data)))
+		le(crc32.ChecksumIEEE([]byte(e.data)))
+	}
+	le(uint32(0))
+	for _, e := range entries {
+		b.WriteString(e.data)
+	}
+	le(crc32.ChecksumIEEE(b.Bytes()))
+	return b.Bytes()
+}
+
+var testEntries = []testEntry{
+	{"lua/autorun/test.lua", "print('hello')\n"},
+	{"materials/test/empty.vmt", ""},
+	{"sound/test/beep.wav", "RIFF....WAVE"},
+}
+
+func writeTestGMA(t *testing.T, data []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "test.gma")
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestReadGMA(t *testing.T) {
+	gma, err := ReadGMA(bytes.NewReader(buildGMA(testEntries)))
+	if err != nil {
+		t.Fatalf("ReadGMA: %v", err)
+	}
+
+	if gma.Version != GMAVersion || gma.Name != "Test Addon" || gma.Author != "Author Name" {
+		t.Errorf("header = version %d, name %q, author %q", gma.Version, gma.Name, gma.Author)
+	}
+	meta := gma.Metadata()
+	if meta.Title != "Test Addon" || meta.Description != "desc" || meta.Type != "tool" || len(meta.Tags) != 2 {
+		t.Errorf("Metadata() = %+v", meta)
+	}
+	if len(gma.Files) != len(testEntries) {
+		t.Fatalf("got %d files, want %d", len(gma.Files), len(testEntries))
+	}
+
+	var offset int64
+	for i, e := range testEntries {
+		got := gma.Files[i]
+		want := GMAEntry{Name: e.name, Size: int64(len(e.data)), CRC: crc32.ChecksumIEEE([]byte(e.data)), Offset: offset}
+		if got != want {
+			t.Errorf("file %d = %+v, want %+v", i, got, want)
+		}
+		offset += int64(len(e.data))
+	}
+	if gma.Size() != offset {
+		t.Errorf("Size() = %d, want %d", gma.Size(), offset)
+	}
+}
+
+func TestReadGMAPlainDescription(t *testing.T) {
+	gma := &GMA{Name: "Test Addon", Description: "just some text"}
+	meta := gma.Metadata()
+	if meta.Description != "just some text" || meta.Title != "Test Addon" {
+		t.Errorf("Metadata() = %+v, want the raw description and the name as title", meta)
+	}
+}
+
+func TestReadGMABadIdent(t *testing.T) {
+	data := buildGMA(testEntries)
+	copy(data, "GMAX")
+	if _, err := ReadGMA(bytes.NewReader(data)); err == nil {
+		t.Error("ReadGMA accepted a bad ident")
+	}
+}
+
+func TestReadGMAUnsupportedVersion(t *testing.T) {
+	data := buildGMA(testEntries)
+	data[len(GMAIdent)] = GMAVersion + 1
+	if _, err := ReadGMA(bytes.NewReader(data)); err == nil {
+		t.Error("ReadGMA accepted an unsupported version")
+	}
+}
+
+func TestReadGMANegativeSize(t *testing.T) {
+	data := buildGMA(testEntries[:1])
+	gma, err := ReadGMA(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The size follows the name of the first entry, 12 bytes before the
+	// crc and terminating file number
+	sizeOffset := gma.DataOffset - 4 - 4 - 8
+	binary.LittleEndian.PutUint64(data[sizeOffset:], uint64(1<<63))
+	if _, err := ReadGMA(bytes.NewReader(data)); err == nil {
+		t.Error("ReadGMA accepted a negative size")
+	}
+}
+
+func TestReadGMATruncatedHeader(t *testing.T) {
+	data := buildGMA(testEntries)
+	gma, err := ReadGMA(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for n := int64(0); n < gma.DataOffset; n++ {
+		if _, err := ReadGMA(bytes.NewReader(data[:n])); err == nil {
+			t.Errorf("ReadGMA accepted a header truncated to %d bytes", n)
+		}
+	}
+}
+
+func TestExtractGMA(t *testing.T) {
+	src := writeTestGMA(t, buildGMA(testEntries))
+	dest := t.TempDir()
+	if err := ExtractGMA(src, dest); err != nil {
+		t.Fatalf("ExtractGMA: %v", err)
+	}
+
+	for _, e := range testEntries {
+		data, err := os.ReadFile(filepath.Join(dest, filepath.FromSlash(e.name)))
+		if err != nil {
+			t.Errorf("%s: %v", e.name, err)
+			continue
+		}
+		if string(data) != e.data {
+			t.Errorf("%s = %q, want %q", e.name, data, e.data)
+		}
+	}
+}
+
+func TestExtractGMATruncatedData(t *testing.T) {
+	data := buildGMA(testEntries)
+
+	// Cut off the trailing crc and the end of the last file
+	src := writeTestGMA(t, data[:len(data)-4-3])
+	if err := ExtractGMA(src, t.TempDir()); err == nil {
+		t.Error("ExtractGMA accepted a truncated archive")
+	}
+}
+
+func TestExtractGMAPathTraversal(t *testing.T) {
+	src := writeTestGMA(t, buildGMA([]testEntry{{"../escape.lua", "x"}}))
+	dest := filepath.Join(t.TempDir(), "out")
+	if err := ExtractGMA(src, dest); err == nil {
+		t.Error("ExtractGMA wrote outside the destination")
+	}
+	if _, err := os.Stat(filepath.Join(filepath.Dir(dest), "escape.lua")); err == nil {
+		t.Error("escape.lua was created")
+	}
+}
+
+// packTestAddon writes testEntries and an addon.json to a directory and
+// packs it
+func packTestAddon(t *testing.T) (string, string) {
+	t.Helper()
+	src := t.TempDir()
+	for _, e := range testEntries {
+		path := filepath.Join(src, filepath.FromSlash(e.name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(e.data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	addonJSON := `{"title":"Packed","type":"tool","tags":["fun"],"ignore":["*.psd"]}`
+	if err := os.WriteFile(filepath.Join(src, AddonJSONName), []byte(addonJSON), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(src, "materials", "source.psd"), []byte("ignored"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	dst := filepath.Join(t.TempDir(), "packed.gma")
+	if err := PackGMA(src, dst, nil); err != nil {
+		t.Fatalf("PackGMA: %v", err)
+	}
+	return src, dst
+}
+
+func TestPackGMARoundTrip(t *testing.T) {
+	_, dst := packTestAddon(t)
+
+	gma, err := OpenGMA(dst)
+	if err != nil {
+		t.Fatalf("OpenGMA: %v", err)
+	}
+	defer gma.Close()
+
+	if gma.Name != "Packed" {
+		t.Errorf("Name = %q, want Packed", gma.Name)
+	}
+	if meta := gma.Metadata(); meta.Type != "tool" || len(meta.Tags) != 1 || meta.Tags[0] != "fun" {
+		t.Errorf("Metadata() = %+v", meta)
+	}
+
+	want := map[string]string{}
+	for _, e := range testEntries {
+		want[e.name] = e.data
+	}
+	if len(gma.Files) != len(want) {
+		t.Fatalf("packed %d files, want %d (addon.json and ignored files left out)", len(gma.Files), len(want))
+	}
+	for _, entry := range gma.Files {
+		data, ok := want[entry.Name]
+		if !ok {
+			t.Errorf("unexpected file %s", entry.Name)
+			continue
+		}
+		if entry.Size != int64(len(data)) || entry.CRC != crc32.ChecksumIEEE([]byte(data)) {
+			t.Errorf("%s: size %d crc %08x, want size %d crc %08x", entry.Name, entry.Size, entry.CRC, len(data), crc32.ChecksumIEEE([]byte(data)))
+		}
+		var contents bytes.Buffer
+		if _, err := contents.ReadFrom(gma.Open(entry)); err != nil {
+			t.Fatal(err)
+		}
+		if contents.String() != data {
+			t.Errorf("%s = %q, want %q", entry.Name, contents.String(), data)
+		}
+	}
+
+	// The trailing crc covers everything before it
+	data, err := os.ReadFile(dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, trailer := data[:len(data)-4], data[len(data)-4:]
+	if got, want := binary.LittleEndian.Uint32(trailer), crc32.ChecksumIEEE(body); got != want {
+		t.Errorf("trailing crc = %08x, want %08x", got, want)
+	}
+}
+
+func TestPackGMALowercasesNames(t *testing.T) {
+	src := t.TempDir()
+	luaPath := filepath.Join(src, "lua", "autorun", "MyAddon.lua")
+	if err := os.MkdirAll(filepath.Dir(luaPath), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(luaPath, []byte("print('hi')"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	dst := filepath.Join(t.TempDir(), "packed.gma")
+	if err := PackGMA(src, dst, &GMAMetadata{Title: "Mixed Case"}); err != nil {
+		t.Fatalf("PackGMA: %v", err)
+	}
+	gma, err := OpenGMA(dst)
+	if err != nil {
+		t.Fatalf("OpenGMA: %v", err)
+	}
+	defer gma.Close()
+	if len(gma.Files) != 1 || gma.Files[0].Name != "lua/autorun/myaddon.lua" {
+		t.Errorf("files = %+v, want lua/autorun/myaddon.lua", gma.Files)
+	}
+
+	// Two files that only differ in case can't both be packed
+	if err := os.WriteFile(filepath.Join(src, "lua", "autorun", "myaddon.lua"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if entries, _ := os.ReadDir(filepath.Join(src, "lua", "autorun")); len(entries) != 2 {
+		t.Skip("the file system isn't case sensitive")
+	}
+	if err := PackGMA(src, dst, &GMAMetadata{Title: "Mixed Case"}); err == nil {
+		t.Error("packing files that clash in lower case succeeded")
+	}
+}
+
+func TestPackGMARejectsWhitelistViolations(t *testing.T) {
+	src := t.TempDir()
+	for _, name := range []string{"lua/autorun/test.lua", "lua/autorun/Setup.EXE"} {
+		path := filepath.Join(src, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	dst := filepath.Join(t.TempDir(), "packed.gma")
+	err := PackGMA(src, dst, &GMAMetadata{Title: "Bad"})
+	if err == nil || !strings.Contains(err.Error(), "lua/autorun/Setup.EXE") {
+		t.Errorf("PackGMA error = %v, want one naming lua/autorun/Setup.EXE", err)
+	}
+}
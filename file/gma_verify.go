@@ -0,0 +1,149 @@
+package file
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// GMAReport is the result of checking a GMA archive against its own checksums
+type GMAReport struct {
+	GMA *GMA
+
+	// CorruptFiles lists entries whose contents don't match their CRC32
+	CorruptFiles []string
+
+	// Truncated is set when the archive ends before the last file's contents
+	Truncated bool
+
+	// HasCRC is false when the archive has no trailing CRC (or it is zero)
+	HasCRC      bool
+	CRC         uint32
+	ComputedCRC uint32
+}
+
+// OK reports whether the archive passed every check
+func (r *GMAReport) OK() bool {
+	return !r.Truncated && len(r.CorruptFiles) == 0 && (!r.HasCRC || r.CRC == r.ComputedCRC)
+}
+
+// VerifyGMA checks the magic, version, per-file CRC32s and trailing CRC of
+// a GMA archive. A malformed header is returned as an error.
+func VerifyGMA(path string) (*GMAReport, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open gma file: %w", err)
+	}
+	defer f.Close()
+
+	gma, err := ReadGMA(f)
+	if err != nil {
+		return nil, err
+	}
+	report := &GMAReport{GMA: gma}
+
+	// Re-read from the start so the archive CRC covers the header too
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("failed to seek gma file: %w", err)
+	}
+	r := bufio.NewReader(f)
+	archiveCRC := crc32.NewIEEE()
+	if _, err := io.CopyN(archiveCRC, r, gma.DataOffset); err != nil {
+		return nil, fmt.Errorf("failed to read gma header: %w", err)
+	}
+
+	for _, entry := range gma.Files {
+		entryCRC := crc32.NewIEEE()
+		n, err := io.CopyN(io.MultiWriter(archiveCRC, entryCRC), r, entry.Size)
+		if errors.Is(err, io.EOF) || n != entry.Size {
+			report.Truncated = true
+			report.CorruptFiles = append(report.CorruptFiles, entry.Name)
+			return report, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", entry.Name, err)
+		}
+		if entryCRC.Sum32() != entry.CRC {
+			report.CorruptFiles = append(report.CorruptFiles, entry.Name)
+		}
+	}
+
+	report.ComputedCRC = archiveCRC.Sum32()
+	if err := binary.Read(r, binary.LittleEndian, &report.CRC); err == nil {
+		report.HasCRC = report.CRC != 0
+	}
+
+	return report, nil
+}
+
+// TreeDiff lists the differences between an archive's file table and an
+// extracted directory
+type TreeDiff struct {
+	Missing  []string
+	Extra    []string
+	Modified []string
+}
+
+// OK reports whether the directory matches the archive exactly
+func (d *TreeDiff) OK() bool {
+	return len(d.Missing) == 0 && len(d.Extra) == 0 && len(d.Modified) == 0
+}
+
+// CompareGMATree compares the extracted files in dir against the file table
+// of an archive, using sizes and CRC32s to detect modified files
+func CompareGMATree(gma *GMA, dir string) (*TreeDiff, error) {
+	diff := &TreeDiff{}
+	expected := make(map[string]GMAEntry, len(gma.Files))
+	for _, entry := range gma.Files {
+		expected[entry.Name] = entry
+	}
+
+	found := make(map[string]bool, len(gma.Files))
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+
+		entry, ok := expected[rel]
+		if !ok {
+			diff.Extra = append(diff.Extra, rel)
+			return nil
+		}
+		found[rel] = true
+
+		size, crc, err := crcFile(path)
+		if err != nil {
+			return err
+		}
+		if size != entry.Size || crc != entry.CRC {
+			diff.Modified = append(diff.Modified, rel)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan addon directory: %w", err)
+	}
+
+	for _, entry := range gma.Files {
+		if !found[entry.Name] {
+			diff.Missing = append(diff.Missing, entry.Name)
+		}
+	}
+
+	return diff, nil
+}
@@ -0,0 +1,157 @@
+package file
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// readPackedTestAddon packs the test addon and returns the archive's bytes
+// along with its parsed header
+func readPackedTestAddon(t *testing.T) ([]byte, *GMA) {
+	t.Helper()
+	_, dst := packTestAddon(t)
+	data, err := os.ReadFile(dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gma, err := ReadGMA(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data, gma
+}
+
+func TestVerifyGMA(t *testing.T) {
+	data, gma := readPackedTestAddon(t)
+	firstFile := gma.DataOffset + gma.Files[0].Offset
+
+	tests := []struct {
+		name        string
+		corrupt     func([]byte) []byte
+		ok          bool
+		fileCorrupt bool // the first file fails its CRC
+		hasCRC      bool
+		crcOK       bool
+	}{
+		{"intact", func(b []byte) []byte { return b }, true, false, true, true},
+		{"bad file crc", func(b []byte) []byte { b[firstFile] ^= 0xff; return b }, false, true, true, false},
+		{"bad trailing crc", func(b []byte) []byte { b[len(b)-1] ^= 0xff; return b }, false, false, true, false},
+		{"no trailing crc", func(b []byte) []byte { return b[:len(b)-4] }, true, false, false, false},
+		{"zero trailing crc", func(b []byte) []byte { copy(b[len(b)-4:], []byte{0, 0, 0, 0}); return b }, true, false, false, false},
+	}
+	for _, tt := range tests {
+		report, err := VerifyGMA(writeTestGMA(t, tt.corrupt(bytes.Clone(data))))
+		if err != nil {
+			t.Errorf("%s: VerifyGMA: %v", tt.name, err)
+			continue
+		}
+		if report.OK() != tt.ok || report.HasCRC != tt.hasCRC || report.Truncated {
+			t.Errorf("%s: report = %+v, want ok %v, has crc %v", tt.name, report, tt.ok, tt.hasCRC)
+		}
+		if corrupt := len(report.CorruptFiles) == 1 && report.CorruptFiles[0] == gma.Files[0].Name; corrupt != tt.fileCorrupt {
+			t.Errorf("%s: corrupt files = %v", tt.name, report.CorruptFiles)
+		}
+		if tt.hasCRC && (report.CRC == report.ComputedCRC) != tt.crcOK {
+			t.Errorf("%s: crc %08x, computed %08x", tt.name, report.CRC, report.ComputedCRC)
+		}
+	}
+}
+
+func TestVerifyGMATruncated(t *testing.T) {
+	data, gma := readPackedTestAddon(t)
+
+	for n := int64(0); n < int64(len(data))-4; n++ {
+		report, err := VerifyGMA(writeTestGMA(t, data[:n]))
+		switch {
+		case n < gma.DataOffset && err == nil:
+			t.Errorf("VerifyGMA accepted a header truncated to %d bytes", n)
+		case n >= gma.DataOffset && n < gma.DataOffset+gma.Size() && (err != nil || !report.Truncated || report.OK()):
+			t.Errorf("archive truncated to %d bytes: report %+v, err %v", n, report, err)
+		}
+	}
+}
+
+func TestCompareGMATree(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(t *testing.T, dir string)
+		want   TreeDiff
+	}{
+		{
+			name:   "intact",
+			change: func(*testing.T, string) {},
+		},
+		{
+			name: "missing file",
+			change: func(t *testing.T, dir string) {
+				if err := os.Remove(filepath.Join(dir, "sound", "test", "beep.wav")); err != nil {
+					t.Fatal(err)
+				}
+			},
+			want: TreeDiff{Missing: []string{"sound/test/beep.wav"}},
+		},
+		{
+			name: "extra file",
+			change: func(t *testing.T, dir string) {
+				if err := os.WriteFile(filepath.Join(dir, "lua", "autorun", "extra.lua"), nil, 0644); err != nil {
+					t.Fatal(err)
+				}
+			},
+			want: TreeDiff{Extra: []string{"lua/autorun/extra.lua"}},
+		},
+		{
+			name: "same size, other contents",
+			change: func(t *testing.T, dir string) {
+				if err := os.WriteFile(filepath.Join(dir, "sound", "test", "beep.wav"), []byte("RIFF....WAVF"), 0644); err != nil {
+					t.Fatal(err)
+				}
+			},
+			want: TreeDiff{Modified: []string{"sound/test/beep.wav"}},
+		},
+		{
+			name: "other size",
+			change: func(t *testing.T, dir string) {
+				if err := os.WriteFile(filepath.Join(dir, "materials", "test", "empty.vmt"), []byte("x"), 0644); err != nil {
+					t.Fatal(err)
+				}
+			},
+			want: TreeDiff{Modified: []string{"materials/test/empty.vmt"}},
+		},
+	}
+
+	_, dst := packTestAddon(t)
+	gma, err := OpenGMA(dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer gma.Close()
+
+	for _, tt := range tests {
+		dir := t.TempDir()
+		if err := ExtractGMA(dst, dir); err != nil {
+			t.Fatalf("ExtractGMA: %v", err)
+		}
+		tt.change(t, dir)
+
+		diff, err := CompareGMATree(gma.GMA, dir)
+		if err != nil {
+			t.Errorf("%s: CompareGMATree: %v", tt.name, err)
+			continue
+		}
+		if !slices.Equal(diff.Missing, tt.want.Missing) || !slices.Equal(diff.Extra, tt.want.Extra) || !slices.Equal(diff.Modified, tt.want.Modified) {
+			t.Errorf("%s: diff = %+v, want %+v", tt.name, diff, tt.want)
+		}
+		if diff.OK() != tt.want.OK() {
+			t.Errorf("%s: OK() = %v", tt.name, diff.OK())
+		}
+	}
+
+	// The source tree has addon.json and the ignored file on top
+	src, _ := packTestAddon(t)
+	if diff, err := CompareGMATree(gma.GMA, src); err != nil || len(diff.Extra) != 2 || len(diff.Missing) != 0 || len(diff.Modified) != 0 {
+		t.Errorf("source tree diff = %+v, %v, want addon.json and the ignored file as extras", diff, err)
+	}
+}
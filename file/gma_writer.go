@@ -0,0 +1,257 @@
+package file
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// AddonJSONName is the metadata file gmad reads when creating an archive
+const AddonJSONName = "addon.json"
+
+// ReadAddonJSON reads the addon.json metadata file from an addon directory.
+// The returned error wraps os.ErrNotExist when the directory has none.
+func ReadAddonJSON(dir string) (*GMAMetadata, error) {
+	data, err := os.ReadFile(filepath.Join(dir, AddonJSONName))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", AddonJSONName, err)
+	}
+
+	var meta GMAMetadata
+	if err := json.Unmarshal(data, &meta); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", AddonJSONName, err)
+	}
+
+	return &meta, nil
+}
+
+// PackGMA builds a GMA archive at dst from the files in src.
+// If meta is nil the metadata is read from src/addon.json.
+func PackGMA(src, dst string, meta *GMAMetadata) error {
+	if meta == nil {
+		var err error
+		if meta, err = ReadAddonJSON(src); err != nil {
+			return err
+		}
+	}
+
+	files, err := collectAddonFiles(src, meta.Ignore)
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		return fmt.Errorf("no files to pack in %s", src)
+	}
+
+	outFile, err := os.Create(dst)
+	if err != nil {
+		return fmt.Errorf("failed to create gma file: %w", err)
+	}
+	defer outFile.Close()
+
+	w := bufio.NewWriter(outFile)
+	if err := WriteGMA(w, src, files, *meta); err != nil {
+		return err
+	}
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("failed to write gma file: %w", err)
+	}
+
+	return outFile.Sync()
+}
+
+// collectAddonFiles returns the slash-separated paths of every file in dir
+// that should end up in the archive
+func collectAddonFiles(dir string, ignore []string) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+
+		if rel == AddonJSONName {
+			return nil
+		}
+		for _, pattern := range ignore {
+			if MatchWildcard(pattern, rel) {
+				return nil
+			}
+		}
+
+		files = append(files, rel)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list addon files: %w", err)
+	}
+	return files, nil
+}
+
+// gmaWriter writes the little-endian primitives used by the GMA format,
+// remembering the first error so the header can be written in one go
+type gmaWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (w *gmaWriter) write(v any) {
+	if w.err == nil {
+		w.err = binary.Write(w.w, binary.LittleEndian, v)
+	}
+}
+
+func (w *gmaWriter) writeString(s string) {
+	if w.err == nil {
+		_, w.err = io.WriteString(w.w, s+"\x00")
+	}
+}
+
+// WriteGMA writes a GMA v3 archive containing files (paths relative to dir)
+// followed by the CRC32 of the whole archive. Like gmad, the paths are
+// stored in lower case, which the game expects, and files the game's
+// whitelist doesn't allow are refused.
+func WriteGMA(out io.Writer, dir string, files []string, meta GMAMetadata) error {
+	names := make([]string, len(files))
+	packed := make(map[string]string, len(files))
+	for i, name := range files {
+		names[i] = strings.ToLower(name)
+		if other, ok := packed[names[i]]; ok {
+			return fmt.Errorf("%s and %s would both be packed as %s", other, name, names[i])
+		}
+		packed[names[i]] = name
+	}
+	if violations := CheckWhitelist(names); len(violations) > 0 {
+		paths := make([]string, len(violations))
+		for i, violation := range violations {
+			paths[i] = packed[violation.Path]
+		}
+		return fmt.Errorf("files not allowed by the gmod whitelist: %s", strings.Join(paths, ", "))
+	}
+
+	crc := crc32.NewIEEE()
+	w := &gmaWriter{w: io.MultiWriter(out, crc)}
+
+	// gmad only stores description, type and tags in the description field
+	if meta.Tags == nil {
+		meta.Tags = []string{}
+	}
+	description, err := json.Marshal(GMAMetadata{
+		Description: meta.Description,
+		Type:        meta.Type,
+		Tags:        meta.Tags,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal description: %w", err)
+	}
+
+	// Header
+	w.write([]byte(GMAIdent))
+	w.write(byte(GMAVersion))
+	w.write(uint64(0))                 // steamid (unused)
+	w.write(uint64(time.Now().Unix())) // timestamp
+	w.writeString("")                  // required content (unused)
+	w.writeString(meta.Title)
+	w.writeString(string(description))
+	w.writeString("Author Name") // author (unused)
+	w.write(int32(1))            // addon version (unused)
+
+	// File table
+	sizes := make([]int64, len(files))
+	for i, name := range files {
+		size, fileCRC, err := crcFile(filepath.Join(dir, filepath.FromSlash(name)))
+		if err != nil {
+			return err
+		}
+		sizes[i] = size
+
+		w.write(uint32(i + 1))
+		w.writeString(names[i])
+		w.write(size)
+		w.write(fileCRC)
+	}
+	w.write(uint32(0))
+	if w.err != nil {
+		return fmt.Errorf("failed to write gma header: %w", w.err)
+	}
+
+	// File contents
+	for i, name := range files {
+		f, err := os.Open(filepath.Join(dir, filepath.FromSlash(name)))
+		if err != nil {
+			return fmt.Errorf("failed to open %s: %w", name, err)
+		}
+		n, err := io.Copy(w.w, f)
+		f.Close()
+		if err != nil {
+			return fmt.Errorf("failed to write %s: %w", name, err)
+		}
+		if n != sizes[i] {
+			return fmt.Errorf("file %s changed while packing", name)
+		}
+	}
+
+	// Trailing CRC of everything written so far
+	if err := binary.Write(out, binary.LittleEndian, crc.Sum32()); err != nil {
+		return fmt.Errorf("failed to write gma crc: %w", err)
+	}
+
+	return nil
+}
+
+// crcFile returns the size and CRC32 of a file
+func crcFile(path string) (int64, uint32, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer f.Close()
+
+	hash := crc32.NewIEEE()
+	n, err := io.Copy(hash, f)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to read file: %w", err)
+	}
+	return n, hash.Sum32(), nil
+}
+
+// MatchWildcard reports whether name matches a gmad-style wildcard pattern.
+// '*' matches any sequence of characters, including path separators.
+func MatchWildcard(pattern, name string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == '*' {
+			pattern = pattern[1:]
+			if pattern == "" {
+				return true
+			}
+			for i := 0; i <= len(name); i++ {
+				if MatchWildcard(pattern, name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if name == "" || pattern[0] != name[0] {
+			return false
+		}
+		pattern = pattern[1:]
+		name = name[1:]
+	}
+	return name == ""
+}
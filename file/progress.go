@@ -0,0 +1,40 @@
+package file
+
+import "io"
+
+// ProgressFunc is called as bytes are processed, with the total when known
+// and -1 otherwise
+type ProgressFunc func(done, total int64)
+
+// progressReader reports the bytes read through it, at most once per
+// percent so callers aren't flooded on large files
+type progressReader struct {
+	r           io.Reader
+	done, total int64
+	reported    int64
+	fn          ProgressFunc
+}
+
+// NewProgressReader wraps r so fn is called as it is read. A nil fn
+// returns r unchanged.
+func NewProgressReader(r io.Reader, total int64, fn ProgressFunc) io.Reader {
+	if fn == nil {
+		return r
+	}
+	return &progressReader{r: r, total: total, reported: -1, fn: fn}
+}
+
+func (p *progressReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	p.done += int64(n)
+
+	step := p.done >> 20 // every MiB when the total is unknown
+	if p.total > 0 {
+		step = p.done * 100 / p.total
+	}
+	if step != p.reported || err == io.EOF {
+		p.reported = step
+		p.fn(p.done, p.total)
+	}
+	return n, err
+}
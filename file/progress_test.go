@@ -0,0 +1,56 @@
+package file
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+func TestProgressReader(t *testing.T) {
+	tests := []struct {
+		name     string
+		size     int
+		total    int64
+		maxCalls int
+	}{
+		{name: "known total", size: 1 << 20, total: 1 << 20, maxCalls: 102},
+		{name: "unknown total", size: 3<<20 + 10, total: -1, maxCalls: 5},
+		{name: "empty", size: 0, total: 0, maxCalls: 1},
+	}
+
+	for _, tt := range tests {
+		var calls int
+		var lastDone, lastTotal int64
+		r := NewProgressReader(bytes.NewReader(make([]byte, tt.size)), tt.total, func(done, total int64) {
+			if done < lastDone {
+				t.Errorf("%s: progress went back from %d to %d", tt.name, lastDone, done)
+			}
+			calls++
+			lastDone, lastTotal = done, total
+		})
+
+		// Small reads would report on every call without the throttling
+		buf := make([]byte, 1024)
+		for {
+			if _, err := r.Read(buf); err == io.EOF {
+				break
+			} else if err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if lastDone != int64(tt.size) || lastTotal != tt.total {
+			t.Errorf("%s: last report %d/%d, want %d/%d", tt.name, lastDone, lastTotal, tt.size, tt.total)
+		}
+		if calls == 0 || calls > tt.maxCalls {
+			t.Errorf("%s: reported %d times, want 1 to %d", tt.name, calls, tt.maxCalls)
+		}
+	}
+}
+
+func TestProgressReaderWithoutFunc(t *testing.T) {
+	r := bytes.NewReader(nil)
+	if got := NewProgressReader(r, 0, nil); got != io.Reader(r) {
+		t.Error("NewProgressReader wrapped a reader without a progress func")
+	}
+}
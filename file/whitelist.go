@@ -0,0 +1,181 @@
+package file
+
+import (
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"strings"
+)
+
+// Whitelist is the list of wildcard patterns GMod accepts inside a .gma.
+// Archives containing any other path are refused by gmad and the game.
+// Patterns starting with '!' exclude paths an earlier pattern accepted.
+var Whitelist = []string{
+	"lua/*.lua",
+	"scenes/*.vcd",
+	"particles/*.pcf",
+	"resource/fonts/*.ttf",
+	"scripts/vehicles/*.txt",
+	"resource/localization/*/*.properties",
+	"maps/*.bsp",
+	"maps/*.lmp",
+	"maps/*.nav",
+	"maps/*.ain",
+	"maps/thumb/*.png",
+	"sound/*.wav",
+	"sound/*.mp3",
+	"sound/*.ogg",
+	"materials/*.vmt",
+	"materials/*.vtf",
+	"materials/*.png",
+	"materials/*.jpg",
+	"materials/*.jpeg",
+	"materials/colorcorrection/*.raw",
+	"models/*.mdl",
+	"models/*.vtx",
+	"models/*.phy",
+	"models/*.ani",
+	"models/*.vvd",
+	"gamemodes/*/*.txt",
+	"gamemodes/*/*.fgd",
+	"gamemodes/*/logo.png",
+	"gamemodes/*/icon24.png",
+	"gamemodes/*/gamemode/*.lua",
+	"gamemodes/*/entities/effects/*.lua",
+	"gamemodes/*/entities/weapons/*.lua",
+	"gamemodes/*/entities/entities/*.lua",
+	"gamemodes/*/backgrounds/*.png",
+	"gamemodes/*/backgrounds/*.jpg",
+	"gamemodes/*/backgrounds/*.jpeg",
+	"gamemodes/*/content/models/*.mdl",
+	"gamemodes/*/content/models/*.vtx",
+	"gamemodes/*/content/models/*.phy",
+	"gamemodes/*/content/models/*.ani",
+	"gamemodes/*/content/models/*.vvd",
+	"gamemodes/*/content/materials/*.vmt",
+	"gamemodes/*/content/materials/*.vtf",
+	"gamemodes/*/content/materials/*.png",
+	"gamemodes/*/content/materials/*.jpg",
+	"gamemodes/*/content/materials/*.jpeg",
+	"gamemodes/*/content/materials/colorcorrection/*.raw",
+	"gamemodes/*/content/scenes/*.vcd",
+	"gamemodes/*/content/particles/*.pcf",
+	"gamemodes/*/content/resource/fonts/*.ttf",
+	"gamemodes/*/content/scripts/vehicles/*.txt",
+	"gamemodes/*/content/resource/localization/*/*.properties",
+	"gamemodes/*/content/maps/*.bsp",
+	"gamemodes/*/content/maps/*.nav",
+	"gamemodes/*/content/maps/*.ain",
+	"gamemodes/*/content/maps/thumb/*.png",
+	"gamemodes/*/content/sound/*.wav",
+	"gamemodes/*/content/sound/*.mp3",
+	"gamemodes/*/content/sound/*.ogg",
+	"data_static/*.txt",
+	"data_static/*.dat",
+	"data_static/*.json",
+	"data_static/*.xml",
+	"data_static/*.csv",
+	"data_static/*.dem",
+	"data_static/*.vcd",
+	"data_static/*.vtf",
+	"data_static/*.vmt",
+	"data_static/*.png",
+	"data_static/*.jpg",
+	"data_static/*.jpeg",
+	"data_static/*.mp3",
+	"data_static/*.wav",
+	"data_static/*.ogg",
+	"shaders/fxc/*.vcs",
+
+	// '*' crosses directories, so these would otherwise slip through
+	"!models/*.sw.vtx",
+	"!models/*.360.vtx",
+	"!models/*.xbox.vtx",
+	"!gamemodes/*/*/*.txt",
+	"!gamemodes/*/*/*.fgd",
+}
+
+// WhitelistViolation is a path that GMod would refuse to load
+type WhitelistViolation struct {
+	Path   string
+	Reason string
+}
+
+func (v WhitelistViolation) String() string {
+	return fmt.Sprintf("%s (%s)", v.Path, v.Reason)
+}
+
+// IsWhitelisted reports whether a slash-separated path matches GMod's
+// whitelist and none of its exclusions
+func IsWhitelisted(path string) bool {
+	allowed := false
+	for _, pattern := range Whitelist {
+		if !strings.HasPrefix(pattern, "!") && MatchWildcard(pattern, path) {
+			allowed = true
+			break
+		}
+	}
+	if !allowed {
+		return false
+	}
+
+	for _, pattern := range Whitelist {
+		if exclusion, ok := strings.CutPrefix(pattern, "!"); ok && MatchWildcard(exclusion, path) {
+			return false
+		}
+	}
+	return true
+}
+
+// CheckWhitelist returns every path that is not on the whitelist or that
+// contains upper case characters, which break on case-sensitive filesystems
+func CheckWhitelist(paths []string) []WhitelistViolation {
+	var violations []WhitelistViolation
+	for _, path := range paths {
+		lower := strings.ToLower(path)
+		if !IsWhitelisted(lower) {
+			violations = append(violations, WhitelistViolation{Path: path, Reason: "not allowed"})
+		} else if lower != path {
+			violations = append(violations, WhitelistViolation{Path: path, Reason: "not lower case"})
+		}
+	}
+	return violations
+}
+
+// CheckGMAWhitelist checks the file table of an archive against the whitelist
+func CheckGMAWhitelist(gma *GMA) []WhitelistViolation {
+	paths := make([]string, len(gma.Files))
+	for i, entry := range gma.Files {
+		paths[i] = entry.Name
+	}
+	return CheckWhitelist(paths)
+}
+
+// CheckDirWhitelist checks every file in an addon directory against the
+// whitelist, skipping addon.json
+func CheckDirWhitelist(dir string) ([]WhitelistViolation, error) {
+	var paths []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if rel != AddonJSONName {
+			paths = append(paths, rel)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan addon directory: %w", err)
+	}
+
+	return CheckWhitelist(paths), nil
+}
@@ -0,0 +1,85 @@
+package file
+
+import "testing"
+
+func TestMatchWildcard(t *testing.T) {
+	tests := []struct {
+		pattern, name string
+		want          bool
+	}{
+		{"lua/*.lua", "lua/autorun/init.lua", true},
+		{"lua/*.lua", "lua/init.luac", false},
+		{"*", "", true},
+		{"*.psd", "materials/a.psd", true},
+		{"maps/thumb/*.png", "maps/thumb.png", false},
+		{"gamemodes/*/*.txt", "gamemodes/sandbox/sandbox.txt", true},
+		{"exact", "exact", true},
+		{"exact", "exactly", false},
+	}
+	for _, tt := range tests {
+		if got := MatchWildcard(tt.pattern, tt.name); got != tt.want {
+			t.Errorf("MatchWildcard(%q, %q) = %v, want %v", tt.pattern, tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestIsWhitelisted(t *testing.T) {
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"lua/autorun/init.lua", true},
+		{"materials/models/car/body.vmt", true},
+		{"models/car.mdl", true},
+		{"models/car.dx90.vtx", true},
+		{"models/vehicles/car.dx80.vtx", true},
+		{"gamemodes/mygm/mygm.txt", true},
+		{"gamemodes/mygm/mygm.fgd", true},
+		{"gamemodes/mygm/gamemode/init.lua", true},
+		{"data_static/settings.json", true},
+
+		// Exclusions
+		{"models/car.sw.vtx", false},
+		{"models/vehicles/car.sw.vtx", false},
+		{"models/car.360.vtx", false},
+		{"models/car.xbox.vtx", false},
+		{"gamemodes/mygm/content/readme.txt", false},
+		{"gamemodes/mygm/gamemode/notes.txt", false},
+		{"gamemodes/mygm/content/mygm.fgd", false},
+
+		// Not on the whitelist at all
+		{"addon.json", false},
+		{"lua/init.luac", false},
+		{"materials/source.psd", false},
+		{"data/config.txt", false},
+		{"readme.txt", false},
+	}
+	for _, tt := range tests {
+		if got := IsWhitelisted(tt.path); got != tt.want {
+			t.Errorf("IsWhitelisted(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestCheckWhitelist(t *testing.T) {
+	violations := CheckWhitelist([]string{
+		"lua/autorun/init.lua",
+		"Lua/Autorun/Init.lua",
+		"models/car.sw.vtx",
+		"readme.txt",
+	})
+
+	want := []WhitelistViolation{
+		{Path: "Lua/Autorun/Init.lua", Reason: "not lower case"},
+		{Path: "models/car.sw.vtx", Reason: "not allowed"},
+		{Path: "readme.txt", Reason: "not allowed"},
+	}
+	if len(violations) != len(want) {
+		t.Fatalf("CheckWhitelist = %v, want %v", violations, want)
+	}
+	for i := range want {
+		if violations[i] != want[i] {
+			t.Errorf("violation %d = %v, want %v", i, violations[i], want[i])
+		}
+	}
+}
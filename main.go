@@ -1,85 +1,310 @@
 package main
 
 import (
+	"bufio"
+	"errors"
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
 	"strings"
+	"time"
 
 	"gmod-addon-manager/addon"
 	"gmod-addon-manager/config"
+	"gmod-addon-manager/file"
 	"gmod-addon-manager/tui"
 
 	"github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/term"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 )
 
 func main() {
-	// Load config
-	cfg, err := config.LoadConfig()
+	// Load the config of the target picked by --target, GMAM_TARGET or default_target
+	flags := parseGlobalFlags(os.Args[1:])
+	if flags.configPath != "" {
+		config.SetConfigPath(flags.configPath)
+	}
+
+	// config, target and doctor are how a broken config gets fixed, so they
+	// run without a manager, which would create and reconcile the addon
+	// directories they are looking at, and on the default target if the
+	// selected one doesn't exist. The config is only needed to run them,
+	// not to find them.
+	fixesConfig := runsWithoutManager(newRootCmd(nil, nil, flags.overrides), os.Args[1:])
+
+	cfg, err := config.LoadConfigTarget(flags.target, flags.overrides)
+	if errors.Is(err, config.ErrUnknownTarget) && fixesConfig {
+		fmt.Printf("Warning: %v, using the %s target\n", err, config.DefaultTargetName)
+		cfg, err = config.LoadConfigTarget(config.DefaultTargetName, flags.overrides)
+	}
 	if err != nil {
 		fmt.Printf("Failed to load config: %v\n", err)
 		os.Exit(1)
 	}
 
-	addonManager, err := addon.NewManager(cfg)
-	if err != nil {
-		fmt.Printf("Failed to initialize addon manager: %v\n", err)
+	var addonManager *addon.Manager
+	if !fixesConfig {
+		addonManager, err = addon.NewManager(cfg)
+		if err != nil {
+			fmt.Printf("Failed to initialize addon manager: %v\n", err)
+			fmt.Println("Run `gmod-addon-manager doctor` to check your configuration.")
+			os.Exit(1)
+		}
+	}
+
+	// Without a command the TUI is started, otherwise run in CLI mode
+	// (verbose output is already enabled by default)
+	if err := newRootCmd(addonManager, cfg, flags.overrides).Execute(); err != nil {
+		fmt.Println(err)
 		os.Exit(1)
 	}
+}
+
+// runsWithoutManager reports whether args name one of the commands that are
+// registered on a root command built without a manager
+func runsWithoutManager(rootCmd *cobra.Command, args []string) bool {
+	cmd, _, err := rootCmd.Find(args)
+	return err == nil && cmd != rootCmd
+}
+
+// globalFlags are the persistent flags needed before the config is loaded
+type globalFlags struct {
+	target     string
+	configPath string
+	overrides  config.Overrides
+}
+
+// parseGlobalFlags picks the global flags out of the command line. Errors
+// are left for cobra, which parses the same flags again.
+func parseGlobalFlags(args []string) globalFlags {
+	flags := globalFlags{overrides: make(config.Overrides)}
+	fs := pflag.NewFlagSet("global", pflag.ContinueOnError)
+	fs.ParseErrorsWhitelist.UnknownFlags = true
+	fs.SetOutput(io.Discard)
+	addGlobalFlags(fs, &flags)
+	fs.Parse(args)
 
-	// Check if we should run in TUI mode (no arguments)
-	if len(os.Args) == 1 {
-		// Disable verbose output for TUI mode
-		addonManager.SetVerbose(false)
-		runTUI(addonManager)
-		return
+	for _, setting := range config.Settings {
+		if name := setting.Flag(); name != "" && fs.Changed(name) {
+			flags.overrides[setting.Key], _ = fs.GetString(name)
+		}
 	}
+	return flags
+}
 
-	// Otherwise run in CLI mode (verbose output is already enabled by default)
-	runCLI(addonManager, cfg)
+func addGlobalFlags(fs *pflag.FlagSet, flags *globalFlags) {
+	fs.StringVar(&flags.target, "target", "", "install to manage, from the targets in the config (default $"+config.TargetEnv+" or default_target)")
+	fs.StringVar(&flags.configPath, "config", "", "config file to use (default $"+config.ConfigEnv+" or the user config directory)")
+
+	// Every setting can be overridden for one run, see config.LoadConfigTarget
+	for _, setting := range config.Settings {
+		if name := setting.Flag(); name != "" {
+			fs.String(name, "", fmt.Sprintf("override %s (also $%s)", setting.Key, setting.EnvVar()))
+		}
+	}
 }
 
-func runTUI(manager *addon.Manager) {
-	p := tea.NewProgram(tui.NewModel(manager), tea.WithAltScreen())
+// runTUI starts the TUI. Switching targets opens a new manager for the
+// selected install.
+func runTUI(manager *addon.Manager, cfg *config.Config, overrides config.Overrides, lockTimeout time.Duration) {
+	manager.SetVerbose(false)
+	targets := &tui.Targets{
+		Names:   cfg.TargetNames(),
+		Current: cfg.Target,
+		Open: func(name string) (*addon.Manager, error) {
+			targetCfg, err := config.LoadConfigTarget(name, overrides)
+			if err != nil {
+				return nil, err
+			}
+			targetManager, err := addon.NewManager(targetCfg)
+			if err != nil {
+				return nil, err
+			}
+			targetManager.SetVerbose(false)
+			targetManager.SetLockTimeout(lockTimeout)
+			return targetManager, nil
+		},
+	}
+
+	p := tui.NewProgram(manager, targets, tea.WithAltScreen())
 	if _, err := p.Run(); err != nil {
 		fmt.Printf("Error running TUI: %v\n", err)
 		os.Exit(1)
 	}
 }
 
-func runCLI(manager *addon.Manager, cfg *config.Config) {
+// newRootCmd builds the command tree. Without a manager only the commands
+// that fix the config are available.
+func newRootCmd(manager *addon.Manager, cfg *config.Config, overrides config.Overrides) *cobra.Command {
 	var rootCmd = &cobra.Command{
 		Use:   "gmod-addon-manager",
 		Short: "A TUI for managing Garry's Mod addons",
 		Long:  "A terminal-based application for downloading, installing, and managing Garry's Mod addons",
 	}
 
-	rootCmd.AddCommand(initGetCmd(manager))
-	rootCmd.AddCommand(initEnableCmd(manager))
-	rootCmd.AddCommand(initDisableCmd(manager))
-	rootCmd.AddCommand(initRemoveCmd(manager))
-	rootCmd.AddCommand(initListCmd(manager))
-	rootCmd.AddCommand(initInfoCmd(manager))
+	var lockTimeout time.Duration
+	rootCmd.PersistentFlags().DurationVar(&lockTimeout, "lock-timeout", addon.DefaultLockTimeout, "how long to wait for another instance using the addons directory")
+	// Already applied when the config was loaded, registered for help and parsing
+	addGlobalFlags(rootCmd.PersistentFlags(), &globalFlags{})
+
+	if manager != nil {
+		rootCmd.PersistentPreRun = func(cmd *cobra.Command, args []string) {
+			manager.SetLockTimeout(lockTimeout)
+		}
+		rootCmd.Run = func(cmd *cobra.Command, args []string) {
+			runTUI(manager, cfg, overrides, lockTimeout)
+		}
+
+		rootCmd.AddCommand(initGetCmd(manager))
+		rootCmd.AddCommand(initInstallFileCmd(manager))
+		rootCmd.AddCommand(initSyncCollectionCmd(manager))
+		rootCmd.AddCommand(initEnableCmd(manager))
+		rootCmd.AddCommand(initDisableCmd(manager))
+		rootCmd.AddCommand(initRemoveCmd(manager))
+		rootCmd.AddCommand(initListCmd(manager))
+		rootCmd.AddCommand(initOutdatedCmd(manager))
+		rootCmd.AddCommand(initUpdateCmd(manager))
+		rootCmd.AddCommand(initReconcileCmd(manager))
+		rootCmd.AddCommand(initAdoptCmd(manager))
+		rootCmd.AddCommand(initImportSteamCmd(manager))
+		rootCmd.AddCommand(initInfoCmd(manager))
+		rootCmd.AddCommand(initPackCmd(manager))
+		rootCmd.AddCommand(initVerifyCmd(manager))
+		rootCmd.AddCommand(initLintCmd(manager))
+		rootCmd.AddCommand(initCacheCmd(manager))
+	}
 	rootCmd.AddCommand(initConfigCmd(cfg))
+	rootCmd.AddCommand(initTargetCmd(cfg))
+	rootCmd.AddCommand(initDoctorCmd(cfg))
+	return rootCmd
+}
 
-	if err := rootCmd.Execute(); err != nil {
-		fmt.Println(err)
-		os.Exit(1)
+// readIDFile reads addon IDs from a file, one per line. Blank lines and
+// lines starting with # are ignored.
+func readIDFile(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var ids []string
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		ids = append(ids, line)
 	}
+	return ids, nil
 }
 
 func initGetCmd(manager *addon.Manager) *cobra.Command {
+	var strict bool
+	var noDeps bool
+	var collection string
+	var idFile string
+
+	cmd := &cobra.Command{
+		Use:   "get [addon-id...]",
+		Short: "Download and install addons from Steam Workshop",
+		Args: func(cmd *cobra.Command, args []string) error {
+			if collection != "" {
+				return cobra.NoArgs(cmd, args)
+			}
+			if idFile != "" {
+				return nil
+			}
+			return cobra.MinimumNArgs(1)(cmd, args)
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			manager.SetStrict(strict)
+			manager.SetInstallDependencies(!noDeps)
+
+			if collection != "" {
+				if err := manager.InstallCollection(collection, false); err != nil {
+					fmt.Printf("Error getting collection: %v\n", err)
+					os.Exit(1)
+				}
+				fmt.Printf("Successfully installed collection %s\n", collection)
+				return
+			}
+
+			ids := args
+			if idFile != "" {
+				fileIDs, err := readIDFile(idFile)
+				if err != nil {
+					fmt.Printf("Error reading addon IDs: %v\n", err)
+					os.Exit(1)
+				}
+				ids = append(ids, fileIDs...)
+			}
+
+			failed := 0
+			for _, result := range manager.GetAddons(ids) {
+				kind := "addon"
+				if result.Dependency {
+					kind = "dependency"
+				}
+				if result.Err != nil {
+					failed++
+					fmt.Printf("Error getting %s %s: %v\n", kind, result.ID, result.Err)
+					continue
+				}
+				fmt.Printf("Successfully downloaded and installed %s %s\n", kind, result.ID)
+			}
+			if failed > 0 {
+				os.Exit(1)
+			}
+		},
+	}
+
+	cmd.Flags().BoolVar(&strict, "strict", false, "fail if the addon contains paths outside GMod's whitelist")
+	cmd.Flags().BoolVar(&noDeps, "no-deps", false, "don't install the items an addon requires")
+	cmd.Flags().StringVar(&collection, "collection", "", "install every item of a workshop collection")
+	cmd.Flags().StringVarP(&idFile, "file", "f", "", "read addon IDs from a file, one per line")
+	return cmd
+}
+
+func initInstallFileCmd(manager *addon.Manager) *cobra.Command {
+	var id string
+	var strict bool
+
+	cmd := &cobra.Command{
+		Use:   "install-file [path]",
+		Short: "Install an addon from a local .gma, _legacy.bin or zip file",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			manager.SetStrict(strict)
+
+			installedID, err := manager.InstallFromFile(args[0], id)
+			if err != nil {
+				fmt.Printf("Error installing %s: %v\n", args[0], err)
+				os.Exit(1)
+			}
+			fmt.Printf("Successfully installed %s as addon %s\n", args[0], installedID)
+		},
+	}
+
+	cmd.Flags().StringVar(&id, "id", "", "workshop ID to install the addon under (default: derived from the file)")
+	cmd.Flags().BoolVar(&strict, "strict", false, "fail if the addon contains paths outside GMod's whitelist")
+	return cmd
+}
+
+func initSyncCollectionCmd(manager *addon.Manager) *cobra.Command {
 	return &cobra.Command{
-		Use:   "get [addon-id]",
-		Short: "Download and install an addon from Steam Workshop",
+		Use:   "sync-collection [collection-id]",
+		Short: "Install a workshop collection and disable items removed from it",
 		Args:  cobra.ExactArgs(1),
 		Run: func(cmd *cobra.Command, args []string) {
-			err := manager.GetAddon(args[0])
+			err := manager.InstallCollection(args[0], true)
 			if err != nil {
-				fmt.Printf("Error getting addon: %v\n", err)
+				fmt.Printf("Error syncing collection: %v\n", err)
 				os.Exit(1)
 			}
-			fmt.Printf("Successfully downloaded and installed addon %s\n", args[0])
+			fmt.Printf("Collection %s synced successfully\n", args[0])
 		},
 	}
 }
@@ -145,6 +370,21 @@ func formatAddonInfo(addon addon.Addon) string {
 		fmt.Fprintf(&sb, "Tags: %s\n", strings.Join(addon.Tags, ", "))
 	}
 	fmt.Fprintf(&sb, "Enabled: %t\n", addon.Enabled)
+	if addon.Source != "" {
+		fmt.Fprintf(&sb, "Source: %s\n", addon.Source)
+	}
+	if !addon.InstalledAt.IsZero() {
+		fmt.Fprintf(&sb, "Installed at: %s\n", addon.InstalledAt.Format("2006-01-02 15:04"))
+	}
+	if addon.FileCount > 0 {
+		fmt.Fprintf(&sb, "Files: %d (%s)\n", addon.FileCount, file.FormatSize(addon.Size))
+	}
+	if addon.UpdateAvailable {
+		fmt.Fprintln(&sb, "Update available: true")
+	}
+	if addon.Stale {
+		fmt.Fprintln(&sb, "Workshop info: cached, the workshop could not be reached")
+	}
 	return sb.String()
 }
 
@@ -174,6 +414,223 @@ func initListCmd(manager *addon.Manager) *cobra.Command {
 	}
 }
 
+func initOutdatedCmd(manager *addon.Manager) *cobra.Command {
+	return &cobra.Command{
+		Use:   "outdated",
+		Short: "List installed addons with a newer workshop version",
+		Run: func(cmd *cobra.Command, args []string) {
+			outdated, err := manager.GetOutdatedAddons()
+			if err != nil {
+				fmt.Printf("Error checking for updates: %v\n", err)
+				os.Exit(1)
+			}
+
+			if len(outdated) == 0 {
+				fmt.Println("All addons are up to date")
+				return
+			}
+
+			fmt.Println("Outdated Addons:")
+			fmt.Println("================")
+			for _, o := range outdated {
+				fmt.Printf("%s - %s (installed %s, available %s)\n",
+					o.ID, o.Title,
+					o.Installed.Format("2006-01-02 15:04"),
+					o.Available.Format("2006-01-02 15:04"))
+			}
+		},
+	}
+}
+
+func initUpdateCmd(manager *addon.Manager) *cobra.Command {
+	var all bool
+
+	cmd := &cobra.Command{
+		Use:   "update [addon-id|--all]",
+		Short: "Re-download addons whose workshop version is newer",
+		Args: func(cmd *cobra.Command, args []string) error {
+			if all {
+				return cobra.NoArgs(cmd, args)
+			}
+			return cobra.ExactArgs(1)(cmd, args)
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			if !all {
+				updated, err := manager.UpdateAddon(args[0])
+				if err != nil {
+					fmt.Printf("Error updating addon: %v\n", err)
+					os.Exit(1)
+				}
+				if updated {
+					fmt.Printf("Addon %s updated successfully\n", args[0])
+				} else {
+					fmt.Printf("Addon %s is already up to date\n", args[0])
+				}
+				return
+			}
+
+			updated, failed, err := manager.UpdateAll()
+			if err != nil {
+				fmt.Printf("Error updating addons: %v\n", err)
+				os.Exit(1)
+			}
+			for id, err := range failed {
+				fmt.Printf("Error updating addon %s: %v\n", id, err)
+			}
+			fmt.Printf("%d addon(s) updated, %d failed\n", len(updated), len(failed))
+			if len(failed) > 0 {
+				os.Exit(1)
+			}
+		},
+	}
+
+	cmd.Flags().BoolVar(&all, "all", false, "update every outdated addon")
+	return cmd
+}
+
+func initReconcileCmd(manager *addon.Manager) *cobra.Command {
+	return &cobra.Command{
+		Use:   "reconcile",
+		Short: "Repair the installed-addons manifest from the files on disk",
+		Run: func(cmd *cobra.Command, args []string) {
+			report, err := manager.Reconcile()
+			if err != nil {
+				fmt.Printf("Error reconciling manifest: %v\n", err)
+				os.Exit(1)
+			}
+
+			for _, id := range report.Added {
+				fmt.Printf("added: %s\n", id)
+			}
+			for _, id := range report.Removed {
+				fmt.Printf("removed: %s\n", id)
+			}
+			for _, id := range report.Updated {
+				fmt.Printf("updated: %s\n", id)
+			}
+			fmt.Printf("Manifest reconciled (%d added, %d removed, %d updated)\n",
+				len(report.Added), len(report.Removed), len(report.Updated))
+		},
+	}
+}
+
+func initAdoptCmd(manager *addon.Manager) *cobra.Command {
+	var apply bool
+
+	cmd := &cobra.Command{
+		Use:     "adopt",
+		Aliases: []string{"import"},
+		Short:   "Take over addons installed by hand in the addons directory",
+		Long:    "Scan the addons directory for folders and .gma files that aren't managed yet and move them into the managed layout. Without --apply, only shows what would be done.",
+		Args:    cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			candidates, err := manager.PlanAdopt()
+			if err != nil {
+				fmt.Printf("Error scanning addons directory: %v\n", err)
+				os.Exit(1)
+			}
+			if len(candidates) == 0 {
+				fmt.Println("No unmanaged addons found.")
+				return
+			}
+
+			adoptable := 0
+			for _, c := range candidates {
+				name := filepath.Base(c.Path)
+				if c.Skip != "" {
+					fmt.Printf("  skip   %s (%s): %s\n", name, c.Kind, c.Skip)
+					continue
+				}
+				adoptable++
+				fmt.Printf("  adopt  %s (%s) -> %s [id from %s] %s\n", name, c.Kind, c.ID, c.IDSource, c.Title)
+			}
+
+			if !apply {
+				fmt.Printf("\n%d addon(s) can be adopted. Run again with --apply to move them.\n", adoptable)
+				return
+			}
+
+			failed, err := manager.Adopt(candidates)
+			if err != nil {
+				fmt.Printf("Error adopting addons: %v\n", err)
+				os.Exit(1)
+			}
+			for path, err := range failed {
+				fmt.Printf("Error adopting %s: %v\n", filepath.Base(path), err)
+			}
+			fmt.Printf("Adopted %d addon(s).\n", adoptable-len(failed))
+			if len(failed) > 0 {
+				os.Exit(1)
+			}
+		},
+	}
+
+	cmd.Flags().BoolVar(&apply, "apply", false, "move the addons instead of only listing them")
+	return cmd
+}
+
+func initImportSteamCmd(manager *addon.Manager) *cobra.Command {
+	var apply bool
+	var steamappsDir string
+
+	cmd := &cobra.Command{
+		Use:   "import-steam",
+		Short: "Install the workshop items the Steam client is subscribed to",
+		Long:  "Read the Garry's Mod subscriptions of the Steam client from appworkshop_4000.acf and install them from the copies Steam downloaded. Without --apply, only shows what would be done.",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			if steamappsDir == "" {
+				steamappsDir = manager.DefaultSteamappsDir()
+			}
+			if steamappsDir == "" {
+				fmt.Println("Error: couldn't find the steamapps directory from the GMod directory, use --steamapps")
+				os.Exit(1)
+			}
+
+			items, err := manager.PlanSteamImport(steamappsDir)
+			if err != nil {
+				fmt.Printf("Error reading Steam subscriptions: %v\n", err)
+				os.Exit(1)
+			}
+
+			importable := 0
+			for _, item := range items {
+				if item.Skip != "" {
+					fmt.Printf("  skip    %s: %s\n", item.ID, item.Skip)
+					continue
+				}
+				importable++
+				fmt.Printf("  import  %s from %s\n", item.ID, item.Path)
+			}
+
+			if !apply {
+				fmt.Printf("\n%d of %d subscription(s) can be imported. Run again with --apply to install them.\n", importable, len(items))
+				return
+			}
+
+			failed, err := manager.ImportSteam(items)
+			if err != nil {
+				fmt.Printf("Error importing subscriptions: %v\n", err)
+				os.Exit(1)
+			}
+			for id, err := range failed {
+				fmt.Printf("Error importing addon %s: %v\n", id, err)
+			}
+			fmt.Printf("Imported %d addon(s).\n", importable-len(failed))
+			if importable > len(failed) {
+				fmt.Println("Steam keeps the subscriptions, so Garry's Mod loads both its copies and the imported addons. Unsubscribe from the imported items in Steam.")
+			}
+			if len(failed) > 0 {
+				os.Exit(1)
+			}
+		},
+	}
+
+	cmd.Flags().BoolVar(&apply, "apply", false, "install the subscriptions instead of only listing them")
+	cmd.Flags().StringVar(&steamappsDir, "steamapps", "", "steamapps directory of the Steam client (default: derived from the GMod directory)")
+	return cmd
+}
+
 func initInfoCmd(manager *addon.Manager) *cobra.Command {
 	return &cobra.Command{
 		Use:   "info [addon-id]",
@@ -194,21 +651,379 @@ func initInfoCmd(manager *addon.Manager) *cobra.Command {
 	}
 }
 
-func initConfigCmd(cfg *config.Config) *cobra.Command {
+func initPackCmd(manager *addon.Manager) *cobra.Command {
+	var output string
+
+	cmd := &cobra.Command{
+		Use:   "pack [addon-id|dir]",
+		Short: "Pack an installed addon or a folder into a .gma file",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if output == "" {
+				output = filepath.Base(filepath.Clean(args[0])) + ".gma"
+			}
+
+			// A path to a folder is packed directly using its addon.json
+			var err error
+			if info, statErr := os.Stat(args[0]); statErr == nil && info.IsDir() {
+				err = file.PackGMA(args[0], output, nil)
+			} else {
+				err = manager.PackAddon(args[0], output)
+			}
+			if err != nil {
+				fmt.Printf("Error packing addon: %v\n", err)
+				os.Exit(1)
+			}
+			fmt.Printf("Successfully packed %s into %s\n", args[0], output)
+		},
+	}
+
+	cmd.Flags().StringVarP(&output, "output", "o", "", "output .gma file (default: <addon-id>.gma)")
+	return cmd
+}
+
+func printGMAReport(report *file.GMAReport) {
+	fmt.Printf("Name: %s\n", report.GMA.Name)
+	fmt.Printf("Format: GMA v%d, %d file(s)\n", report.GMA.Version, len(report.GMA.Files))
+	if report.Truncated {
+		fmt.Println("Archive: truncated")
+	}
+	for _, name := range report.CorruptFiles {
+		fmt.Printf("  corrupt: %s\n", name)
+	}
+	if !report.HasCRC {
+		fmt.Println("Archive CRC: not present")
+	} else if report.CRC != report.ComputedCRC {
+		fmt.Printf("Archive CRC: mismatch (expected %08x, got %08x)\n", report.CRC, report.ComputedCRC)
+	} else {
+		fmt.Printf("Archive CRC: ok (%08x)\n", report.CRC)
+	}
+}
+
+func printTreeDiff(diff *file.TreeDiff) {
+	for _, name := range diff.Missing {
+		fmt.Printf("  missing: %s\n", name)
+	}
+	for _, name := range diff.Extra {
+		fmt.Printf("  extra: %s\n", name)
+	}
+	for _, name := range diff.Modified {
+		fmt.Printf("  modified: %s\n", name)
+	}
+	if diff.OK() {
+		fmt.Println("Extracted files: ok")
+	}
+}
+
+func initVerifyCmd(manager *addon.Manager) *cobra.Command {
+	return &cobra.Command{
+		Use:   "verify [addon-id|file.gma]",
+		Short: "Check the integrity of an installed addon or a .gma file",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			// A .gma file is only checked against its own checksums
+			if strings.EqualFold(filepath.Ext(args[0]), ".gma") {
+				report, err := file.VerifyGMA(args[0])
+				if err != nil {
+					fmt.Printf("Error verifying file: %v\n", err)
+					os.Exit(1)
+				}
+				printGMAReport(report)
+				if !report.OK() {
+					os.Exit(1)
+				}
+				return
+			}
+
+			result, err := manager.VerifyAddon(args[0])
+			if err != nil {
+				fmt.Printf("Error verifying addon: %v\n", err)
+				os.Exit(1)
+			}
+			printGMAReport(result.Archive)
+			if result.Tree != nil {
+				printTreeDiff(result.Tree)
+			}
+			if !result.OK() {
+				fmt.Printf("Addon %s failed verification\n", args[0])
+				os.Exit(1)
+			}
+			fmt.Printf("Addon %s verified successfully\n", args[0])
+		},
+	}
+}
+
+func initLintCmd(manager *addon.Manager) *cobra.Command {
+	return &cobra.Command{
+		Use:   "lint [addon-id|dir|file.gma]",
+		Short: "List paths GMod would refuse to load",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			var violations []file.WhitelistViolation
+			var err error
+
+			if strings.EqualFold(filepath.Ext(args[0]), ".gma") {
+				var gma *file.GMAFile
+				if gma, err = file.OpenGMA(args[0]); err == nil {
+					violations = file.CheckGMAWhitelist(gma.GMA)
+					gma.Close()
+				}
+			} else if info, statErr := os.Stat(args[0]); statErr == nil && info.IsDir() {
+				violations, err = file.CheckDirWhitelist(args[0])
+			} else {
+				violations, err = manager.LintAddon(args[0])
+			}
+			if err != nil {
+				fmt.Printf("Error linting addon: %v\n", err)
+				os.Exit(1)
+			}
+
+			if len(violations) == 0 {
+				fmt.Printf("No problems found in %s\n", args[0])
+				return
+			}
+			for _, v := range violations {
+				fmt.Println(v)
+			}
+			fmt.Printf("%d problem(s) found in %s\n", len(violations), args[0])
+			os.Exit(1)
+		},
+	}
+}
+
+func initTargetCmd(cfg *config.Config) *cobra.Command {
+	listTargets := func(cmd *cobra.Command, args []string) {
+		raw, err := config.LoadConfigFile()
+		if err != nil {
+			fmt.Printf("Error loading config: %v\n", err)
+			os.Exit(1)
+		}
+		for _, name := range raw.TargetNames() {
+			target, _ := raw.ForTarget(name)
+			marker := " "
+			if name == cfg.Target {
+				marker = "*"
+			}
+			fmt.Printf("%s %s\t%s\n", marker, name, target.GModDir)
+		}
+	}
+
+	cmd := &cobra.Command{
+		Use:   "target",
+		Short: "Manage the GMod installs in the config",
+		Args:  cobra.NoArgs,
+		Run:   listTargets,
+	}
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "list",
+		Short: "List the targets, marking the selected one",
+		Args:  cobra.NoArgs,
+		Run:   listTargets,
+	})
+
+	var force bool
+	addCmd := &cobra.Command{
+		Use:   "add [name] [gmod-dir]",
+		Short: "Add a GMod install, like a dedicated server",
+		Args:  cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			editTargets(force, func(raw *config.Config) error {
+				return raw.AddTarget(args[0], config.Target{GModDir: args[1]})
+			})
+			fmt.Printf("Target %s added, select it with --target %s\n", args[0], args[0])
+		},
+	}
+	addCmd.Flags().BoolVar(&force, "force", false, "save even if the config doesn't validate")
+	cmd.AddCommand(addCmd)
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "remove [name]",
+		Short: "Remove a target from the config, leaving its files alone",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			editTargets(true, func(raw *config.Config) error {
+				return raw.RemoveTarget(args[0])
+			})
+			fmt.Printf("Target %s removed\n", args[0])
+		},
+	})
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "use [name]",
+		Short: "Select the target used when neither --target nor " + config.TargetEnv + " is given",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			editTargets(true, func(raw *config.Config) error {
+				if _, err := raw.ForTarget(args[0]); err != nil {
+					return err
+				}
+				raw.DefaultTarget = args[0]
+				if args[0] == config.DefaultTargetName {
+					raw.DefaultTarget = ""
+				}
+				return nil
+			})
+			fmt.Printf("Using target %s by default\n", args[0])
+		},
+	})
+
+	return cmd
+}
+
+// editTargets changes the config file and saves it, exiting on failure
+func editTargets(force bool, edit func(*config.Config) error) {
+	raw, err := config.LoadConfigFile()
+	if err != nil {
+		fmt.Printf("Error loading config: %v\n", err)
+		os.Exit(1)
+	}
+	if err := edit(raw); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	if !saveConfig(raw, force) {
+		os.Exit(1)
+	}
+}
+
+func initCacheCmd(manager *addon.Manager) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "cache",
+		Short: "Manage the workshop info cache",
+	}
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "stats",
+		Short: "Show how many entries are cached and how many have expired",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			stats, err := manager.Cache().Stats()
+			if err != nil {
+				fmt.Printf("Error reading cache: %v\n", err)
+				os.Exit(1)
+			}
+			fmt.Printf("Directory: %s\n", stats.Dir)
+			fmt.Printf("TTL: %s\n", stats.TTL)
+			fmt.Printf("Entries: %d (%d fresh, %d expired)\n", stats.Entries, stats.Fresh, stats.Stale)
+			if stats.Corrupt > 0 {
+				fmt.Printf("Unreadable: %d\n", stats.Corrupt)
+			}
+			fmt.Printf("Size: %s\n", file.FormatSize(stats.Size))
+			if stats.Entries > 0 {
+				fmt.Printf("Oldest: %s\n", stats.Oldest.Format("2006-01-02 15:04"))
+				fmt.Printf("Newest: %s\n", stats.Newest.Format("2006-01-02 15:04"))
+			}
+		},
+	})
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "clear",
+		Short: "Remove every cached entry",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			removed, err := manager.Cache().Clear()
+			if err != nil {
+				fmt.Printf("Error clearing cache: %v\n", err)
+				os.Exit(1)
+			}
+			fmt.Printf("Removed %d cache file(s)\n", removed)
+		},
+	})
+
+	var olderThanFlag string
+	pruneCmd := &cobra.Command{
+		Use:   "prune",
+		Short: "Remove expired and unreadable entries",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			olderThan := manager.Cache().TTL()
+			if olderThanFlag != "" {
+				var err error
+				if olderThan, err = config.ParseDuration(olderThanFlag); err != nil {
+					fmt.Printf("Error: invalid --older-than: %v\n", err)
+					os.Exit(1)
+				}
+			}
+			removed, err := manager.Cache().Prune(olderThan)
+			if err != nil {
+				fmt.Printf("Error pruning cache: %v\n", err)
+				os.Exit(1)
+			}
+			fmt.Printf("Removed %d cache file(s) older than %s\n", removed, olderThan)
+		},
+	}
+	pruneCmd.Flags().StringVar(&olderThanFlag, "older-than", "", "remove entries older than this, like 7d or 72h (default the cache TTL)")
+	cmd.AddCommand(pruneCmd)
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "warm",
+		Short: "Fetch workshop info for installed addons that aren't cached or have expired",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			fetched, err := manager.WarmCache()
+			if err != nil {
+				fmt.Printf("Error warming cache: %v\n", err)
+				os.Exit(1)
+			}
+			fmt.Printf("Cached workshop info for %d addon(s)\n", fetched)
+		},
+	})
+
+	return cmd
+}
+
+func initDoctorCmd(cfg *config.Config) *cobra.Command {
 	return &cobra.Command{
+		Use:   "doctor",
+		Short: "Check the configuration and environment for problems",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			os.Exit(runDoctor(cfg))
+		},
+	}
+}
+
+// runDoctor prints every config problem with its fix and returns the exit
+// code: 1 if any of them will make installs fail
+func runDoctor(cfg *config.Config) int {
+	problems := config.Validate(cfg)
+	if len(problems) == 0 {
+		fmt.Println("No problems found")
+		return 0
+	}
+
+	for _, p := range problems {
+		fmt.Printf("%s: %s\n", p.Severity, p)
+		if p.Fix != "" {
+			fmt.Printf("  fix: %s\n", p.Fix)
+		}
+	}
+	fmt.Printf("%d problem(s) found\n", len(problems))
+
+	if config.HasErrors(problems) {
+		return 1
+	}
+	return 0
+}
+
+func initConfigCmd(cfg *config.Config) *cobra.Command {
+	var showSecrets bool
+
+	cmd := &cobra.Command{
 		Use:   "config",
 		Short: "Manage configuration",
+		Args:  cobra.NoArgs,
 		Run: func(cmd *cobra.Command, args []string) {
 			// Show current config
 			fmt.Println("Current Configuration:")
 			fmt.Println("======================")
-			fmt.Printf("GMod Directory: %s\n", cfg.GModDir)
-			fmt.Printf("Download Directory: %s\n", cfg.DownloadDir)
-			fmt.Printf("Addon Directory: %s\n", cfg.AddonDir)
-			fmt.Printf("Output Directory: %s\n", cfg.OutDir)
-			fmt.Printf("SteamCMD Path: %s\n", cfg.SteamCmdPath)
-			fmt.Printf("GMAD Path: %s\n", cfg.GMADPath)
-			fmt.Printf("Steam API Key: %s\n", cfg.SteamAPIKey)
+			if len(cfg.Targets) > 0 {
+				fmt.Printf("Target: %s\n", cfg.Target)
+			}
+			for _, setting := range config.Settings {
+				fmt.Printf("%s: %s (%s)\n", setting.Label, setting.Display(cfg, showSecrets), cfg.Source(setting.Key))
+			}
 
 			// Show config file location
 			configPath, err := config.GetConfigPath()
@@ -216,8 +1031,225 @@ func initConfigCmd(cfg *config.Config) *cobra.Command {
 				fmt.Printf("\nWarning: Could not determine config file location: %v\n", err)
 			} else {
 				fmt.Printf("\nConfig file location: %s\n", configPath)
-				fmt.Println("You can edit this file to customize your configuration.")
+				fmt.Println("Use `config set <key> <value>` or `config init` to change it.")
+			}
+		},
+	}
+	cmd.PersistentFlags().BoolVar(&showSecrets, "show-secrets", false, "print secrets such as the Steam API key in clear text")
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "get [key]",
+		Short: "Print the value of a config key",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			setting, err := config.LookupSetting(args[0])
+			if err != nil {
+				fmt.Println(err)
+				os.Exit(1)
 			}
+			fmt.Println(setting.Display(cfg, showSecrets))
+		},
+	})
+
+	var force bool
+	setCmd := &cobra.Command{
+		Use:   "set [key] [value]",
+		Short: "Change a config key",
+		Args:  cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			updateConfigKey(cfg, args[0], args[1], force)
+		},
+	}
+	setCmd.Flags().BoolVar(&force, "force", false, "save even if the config doesn't validate")
+	cmd.AddCommand(setCmd)
+
+	unsetCmd := &cobra.Command{
+		Use:   "unset [key]",
+		Short: "Reset a config key to its default",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			updateConfigKey(cfg, args[0], "", force)
+		},
+	}
+	unsetCmd.Flags().BoolVar(&force, "force", false, "save even if the config doesn't validate")
+	cmd.AddCommand(unsetCmd)
+
+	initCmd := &cobra.Command{
+		Use:   "init",
+		Short: "Walk through the main settings, suggesting detected paths",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			runConfigWizard(cfg.Target, force)
 		},
 	}
+	initCmd.Flags().BoolVar(&force, "force", false, "save even if the config doesn't validate")
+	cmd.AddCommand(initCmd)
+
+	return cmd
+}
+
+// updateConfigKey sets a key of the selected target in the config file,
+// validating the result first
+func updateConfigKey(cfg *config.Config, key, value string, force bool) {
+	target := cfg.Target
+	setting, err := config.LookupSetting(key)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	raw, err := config.LoadConfigFile()
+	if err != nil {
+		fmt.Printf("Error loading config: %v\n", err)
+		os.Exit(1)
+	}
+	err = raw.EditTarget(target, func(selected *config.Config) error {
+		return setting.Set(selected, value)
+	})
+	if err != nil {
+		fmt.Printf("Error setting %s: %v\n", key, err)
+		os.Exit(1)
+	}
+
+	if !saveConfig(raw, force) {
+		os.Exit(1)
+	}
+	selected, _ := raw.ForTarget(target)
+	if value == "" {
+		fmt.Printf("%s reset to its default: %s\n", key, setting.Display(selected.WithDefaults(), false))
+	} else {
+		fmt.Printf("%s set to %s\n", key, setting.Display(selected, false))
+	}
+
+	// The file changed, but this run's value came from somewhere else
+	if source := cfg.Source(key); source != config.SourceDefault && source != config.SourceFile && !strings.HasPrefix(source, "target ") {
+		fmt.Printf("Note: %s is overridden by %s\n", key, source)
+	}
+}
+
+// saveConfig validates and saves the config, printing the problems found.
+// With force, errors are reported but the config is saved anyway.
+func saveConfig(raw *config.Config, force bool) bool {
+	problems, err := config.ValidateAndSave(raw)
+	var invalid *config.ValidationError
+	if errors.As(err, &invalid) && force {
+		err = config.SaveConfig(raw)
+	}
+
+	for _, p := range problems {
+		fmt.Printf("%s: %s\n", p.Severity, p)
+		if p.Fix != "" {
+			fmt.Printf("  fix: %s\n", p.Fix)
+		}
+	}
+
+	if errors.As(err, &invalid) {
+		fmt.Printf("Config not saved, it would introduce %d error(s); fix them or use --force\n", len(invalid.Problems))
+		return false
+	}
+	if err != nil {
+		fmt.Printf("Error saving config: %v\n", err)
+		return false
+	}
+	return true
+}
+
+// runConfigWizard prompts for the main settings. Each prompt defaults to the
+// current value, or to what was detected when there is none.
+func runConfigWizard(target string, force bool) {
+	raw, err := config.LoadConfigFile()
+	if err != nil {
+		fmt.Printf("Error loading config: %v\n", err)
+		os.Exit(1)
+	}
+	selected, err := raw.ForTarget(target)
+	if err != nil {
+		fmt.Printf("Error loading config: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Println("Press enter to keep the suggested value, or type - to reset a setting to its default.")
+	stdin := bufio.NewReader(os.Stdin)
+	for _, setting := range config.Settings {
+		if !setting.Wizard {
+			continue
+		}
+
+		// Earlier answers feed the suggestions, e.g. gmad_path from gmod_dir
+		unset := *selected
+		setting.Set(&unset, "")
+		suggested := setting.Get(unset.WithDefaults())
+		current := setting.Get(selected)
+
+		var answer string
+		if setting.Secret {
+			answer, err = promptSecret(stdin, setting, current)
+		} else {
+			if current != "" && current != suggested {
+				fmt.Printf("  detected: %s\n", suggested)
+			}
+			value := current
+			if value == "" {
+				value = suggested
+			}
+			answer, err = prompt(stdin, fmt.Sprintf("%s [%s]: ", setting.Label, value))
+			if answer == "" {
+				answer = value
+			}
+		}
+		if err != nil {
+			fmt.Printf("\nError reading input: %v\n", err)
+			os.Exit(1)
+		}
+
+		switch answer {
+		case "":
+			continue
+		case "-":
+			answer = ""
+		}
+		if err := setting.Set(selected, answer); err != nil {
+			fmt.Printf("Error setting %s: %v\n", setting.Key, err)
+			os.Exit(1)
+		}
+	}
+
+	raw.EditTarget(target, func(edited *config.Config) error {
+		*edited = *selected
+		return nil
+	})
+
+	if !saveConfig(raw, force) {
+		os.Exit(1)
+	}
+	configPath, _ := config.GetConfigPath()
+	fmt.Printf("Config saved to %s\n", configPath)
+}
+
+func prompt(stdin *bufio.Reader, question string) (string, error) {
+	fmt.Print(question)
+	answer, err := stdin.ReadString('\n')
+	if err == io.EOF {
+		// Piped input ran out, keep the suggestions
+		fmt.Println()
+		return strings.TrimSpace(answer), nil
+	}
+	return strings.TrimSpace(answer), err
+}
+
+// promptSecret reads a secret without echoing it when stdin is a terminal.
+// An empty answer keeps the current value.
+func promptSecret(stdin *bufio.Reader, setting config.Setting, current string) (string, error) {
+	question := fmt.Sprintf("%s: ", setting.Label)
+	if current != "" {
+		question = fmt.Sprintf("%s [%s]: ", setting.Label, config.Mask(current))
+	}
+	if !term.IsTerminal(os.Stdin.Fd()) {
+		return prompt(stdin, question)
+	}
+
+	fmt.Print(question)
+	answer, err := term.ReadPassword(os.Stdin.Fd())
+	fmt.Println()
+	return strings.TrimSpace(string(answer)), err
 }
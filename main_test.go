@@ -0,0 +1,70 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gmod-addon-manager/config"
+)
+
+func TestRunsWithoutManager(t *testing.T) {
+	rootCmd := newRootCmd(nil, &config.Config{}, nil)
+
+	tests := map[string]struct {
+		args []string
+		want bool
+	}{
+		"tui":            {nil, false},
+		"doctor":         {[]string{"doctor"}, true},
+		"config":         {[]string{"config"}, true},
+		"config set":     {[]string{"config", "set", "workers", "4"}, true},
+		"target":         {[]string{"target"}, true},
+		"flag first":     {[]string{"--lock-timeout", "1s", "doctor"}, true},
+		"override first": {[]string{"--gmod-dir", "/tmp/gmod", "config"}, true},
+		"list":           {[]string{"list"}, false},
+		"unknown":        {[]string{"frobnicate"}, false},
+		"doctor later":   {[]string{"get", "doctor"}, false},
+	}
+	for name, tt := range tests {
+		if got := runsWithoutManager(rootCmd, tt.args); got != tt.want {
+			t.Errorf("%s: runsWithoutManager(%q) = %v, want %v", name, tt.args, got, tt.want)
+		}
+	}
+}
+
+func TestDoctorLeavesAddonDirUntouched(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	gmodDir := t.TempDir()
+	cfg := &config.Config{
+		GModDir:     gmodDir,
+		AddonDir:    filepath.Join(gmodDir, "garrysmod", "addons"),
+		OutDir:      filepath.Join(gmodDir, "garrysmod", "addons-managed"),
+		TmpDir:      filepath.Join(t.TempDir(), "tmp"),
+		Downloaders: []string{"http"},
+		CacheTTL:    config.DefaultCacheTTL,
+	}
+	if err := os.MkdirAll(cfg.AddonDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if !runsWithoutManager(newRootCmd(nil, cfg, nil), []string{"doctor"}) {
+		t.Fatal("doctor would build a manager")
+	}
+	if code := runDoctor(cfg); code != 0 {
+		t.Errorf("runDoctor() = %d, want 0", code)
+	}
+
+	entries, err := os.ReadDir(cfg.AddonDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("doctor left %d entries in the addon directory", len(entries))
+	}
+	for _, dir := range []string{cfg.OutDir, cfg.TmpDir} {
+		if _, err := os.Stat(dir); !os.IsNotExist(err) {
+			t.Errorf("doctor created %s", dir)
+		}
+	}
+}
@@ -0,0 +1,216 @@
+package steam
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// Roots returns the Steam client installs found on this machine, most
+// common location first. Links to the same install are only listed once.
+func Roots() []string {
+	homeDir, _ := os.UserHomeDir()
+
+	var candidates []string
+	switch runtime.GOOS {
+	case "windows":
+		candidates = []string{
+			filepath.Join(os.Getenv("ProgramFiles(x86)"), "Steam"),
+			filepath.Join(os.Getenv("ProgramFiles"), "Steam"),
+		}
+	case "darwin":
+		candidates = []string{
+			filepath.Join(homeDir, "Library", "Application Support", "Steam"),
+		}
+	default:
+		candidates = []string{
+			filepath.Join(homeDir, ".steam", "steam"),
+			filepath.Join(homeDir, ".steam", "root"),
+			filepath.Join(homeDir, ".local", "share", "Steam"),
+			// Flatpak and Snap keep the client inside their sandbox
+			filepath.Join(homeDir, ".var", "app", "com.valvesoftware.Steam", ".local", "share", "Steam"),
+			filepath.Join(homeDir, ".var", "app", "com.valvesoftware.Steam", "data", "Steam"),
+			filepath.Join(homeDir, "snap", "steam", "common", ".local", "share", "Steam"),
+		}
+	}
+
+	return existingDirs(candidates, "steamapps")
+}
+
+// existingDirs keeps the candidates containing sub, resolving links and
+// dropping duplicates
+func existingDirs(candidates []string, sub string) []string {
+	var dirs []string
+	seen := make(map[string]bool)
+	for _, candidate := range candidates {
+		resolved, err := filepath.EvalSymlinks(candidate)
+		if err != nil || seen[resolved] {
+			continue
+		}
+		if info, err := os.Stat(filepath.Join(resolved, sub)); err != nil || !info.IsDir() {
+			continue
+		}
+		seen[resolved] = true
+		dirs = append(dirs, resolved)
+	}
+	return dirs
+}
+
+// LibraryFolders returns every library of a Steam install, read from
+// steamapps/libraryfolders.vdf. The install itself is always the first.
+func LibraryFolders(root string) ([]string, error) {
+	libraries := []string{root}
+
+	vdf, err := ParseVDFFile(filepath.Join(root, "steamapps", "libraryfolders.vdf"))
+	if os.IsNotExist(err) {
+		return libraries, nil
+	} else if err != nil {
+		return libraries, err
+	}
+
+	folders := vdf.Child("libraryfolders")
+	if folders == nil {
+		return libraries, nil
+	}
+	for _, folder := range folders.Children {
+		// Current files nest a "path" key, older ones map the index to the path
+		path := folder.Get("path")
+		if path == "" && len(folder.Children) == 0 {
+			if _, err := fmt.Sscanf(folder.Key, "%d", new(int)); err == nil {
+				path = folder.Value
+			}
+		}
+		if path == "" {
+			continue
+		}
+		libraries = append(libraries, filepath.Clean(path))
+	}
+
+	return existingDirs(libraries, "steamapps"), nil
+}
+
+// AppInstallDir returns where a library has an app installed, from its
+// steamapps/appmanifest_<appid>.acf
+func AppInstallDir(library, appID string) (string, error) {
+	manifest, err := ParseVDFFile(filepath.Join(library, "steamapps", "appmanifest_"+appID+".acf"))
+	if err != nil {
+		return "", err
+	}
+	installDir := manifest.Get("AppState", "installdir")
+	if installDir == "" {
+		return "", fmt.Errorf("app %s has no installdir in %s", appID, library)
+	}
+	return filepath.Join(library, "steamapps", "common", installDir), nil
+}
+
+// GModInstall is a Garry's Mod install found in a Steam library
+type GModInstall struct {
+	SteamRoot string
+	Library   string
+	Dir       string
+}
+
+// FindGMod looks through the libraries of every Steam install for Garry's Mod
+func FindGMod() (*GModInstall, error) {
+	for _, root := range Roots() {
+		libraries, _ := LibraryFolders(root)
+		for _, library := range libraries {
+			dir, err := AppInstallDir(library, GModAppID)
+			if err != nil {
+				continue
+			}
+			if info, err := os.Stat(dir); err == nil && info.IsDir() {
+				return &GModInstall{SteamRoot: root, Library: library, Dir: dir}, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("garry's mod was not found in any steam library")
+}
+
+// FindGMAD returns the gmad binary shipped with a GMod install, or "" if
+// there is none
+func FindGMAD(gmodDir string) string {
+	candidates := []string{
+		filepath.Join(gmodDir, "bin", "linux64", "gmad"),
+		filepath.Join(gmodDir, "bin", "gmad_linux"),
+		filepath.Join(gmodDir, "bin", "win64", "gmad.exe"),
+		filepath.Join(gmodDir, "bin", "gmad.exe"),
+	}
+	if runtime.GOOS == "windows" {
+		candidates = candidates[2:]
+	}
+	for _, candidate := range candidates {
+		if info, err := os.Stat(candidate); err == nil && !info.IsDir() {
+			return candidate
+		}
+	}
+	return ""
+}
+
+// SteamCmd is a steamcmd install and the steamapps directory it downloads into
+type SteamCmd struct {
+	Path      string
+	SteamApps string
+}
+
+// DownloadDir is where steamcmd puts Garry's Mod workshop items
+func (s *SteamCmd) DownloadDir() string {
+	return WorkshopContentDir(s.SteamApps)
+}
+
+// FindSteamCmd locates the steamcmd binary and the steamapps directory it
+// downloads into
+func FindSteamCmd() (*SteamCmd, error) {
+	homeDir, _ := os.UserHomeDir()
+
+	// Installs unpacked from Valve's archive keep their data next to the script
+	for _, dir := range []string{
+		filepath.Join(homeDir, "steamcmd"),
+		filepath.Join(homeDir, ".steam", "steamcmd"),
+		filepath.Join(homeDir, "Steam"),
+		`C:\steamcmd`,
+	} {
+		for _, name := range []string{"steamcmd.sh", "steamcmd.exe"} {
+			if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+				return &SteamCmd{Path: filepath.Join(dir, name), SteamApps: filepath.Join(dir, "steamapps")}, nil
+			}
+		}
+	}
+
+	path, err := exec.LookPath("steamcmd")
+	if err != nil {
+		return nil, fmt.Errorf("steamcmd was not found")
+	}
+	return SteamCmdAt(path), nil
+}
+
+// SteamCmdAt returns the steamapps directory the steamcmd binary at path
+// downloads into
+func SteamCmdAt(path string) *SteamCmd {
+	// Installs unpacked from Valve's archive keep their data next to the script
+	switch strings.ToLower(filepath.Base(path)) {
+	case "steamcmd.sh", "steamcmd.exe":
+		return &SteamCmd{Path: path, SteamApps: filepath.Join(filepath.Dir(path), "steamapps")}
+	}
+
+	// Distribution packages wrap the script and keep the data in the home
+	// directory, older ones with a capitalized SteamApps
+	homeDir, _ := os.UserHomeDir()
+	for _, dir := range []string{
+		filepath.Join(homeDir, ".steam", "steamcmd"),
+		filepath.Join(homeDir, ".steam"),
+		filepath.Join(homeDir, ".local", "share", "Steam"),
+		filepath.Join(homeDir, ".steam", "steam"),
+	} {
+		for _, name := range []string{"steamapps", "SteamApps"} {
+			steamApps := filepath.Join(dir, name)
+			if info, err := os.Stat(steamApps); err == nil && info.IsDir() {
+				return &SteamCmd{Path: path, SteamApps: steamApps}
+			}
+		}
+	}
+	return &SteamCmd{Path: path, SteamApps: filepath.Join(filepath.Dir(path), "steamapps")}
+}
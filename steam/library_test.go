@@ -0,0 +1,148 @@
+package steam
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"testing"
+)
+
+// tempDir returns a test directory with symlinks resolved, as Roots and
+// LibraryFolders report them
+func tempDir(t *testing.T) string {
+	t.Helper()
+	dir, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func mkdirAll(t *testing.T, dirs ...string) {
+	t.Helper()
+	for _, dir := range dirs {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	mkdirAll(t, filepath.Dir(path))
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// fakeLinuxHome points the home directory at a Steam install in
+// ~/.local/share/Steam, linked from ~/.steam like the client does
+func fakeLinuxHome(t *testing.T) string {
+	t.Helper()
+	if runtime.GOOS == "windows" || runtime.GOOS == "darwin" {
+		t.Skip("steam locations under the home directory are only searched on linux")
+	}
+	home := tempDir(t)
+	t.Setenv("HOME", home)
+
+	root := filepath.Join(home, ".local", "share", "Steam")
+	mkdirAll(t, filepath.Join(root, "steamapps"), filepath.Join(home, ".steam"))
+	for _, link := range []string{"steam", "root"} {
+		if err := os.Symlink(root, filepath.Join(home, ".steam", link)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+func TestRoots(t *testing.T) {
+	root := fakeLinuxHome(t)
+
+	if got := Roots(); !slices.Equal(got, []string{root}) {
+		t.Errorf("Roots() = %v, want %v", got, []string{root})
+	}
+}
+
+func TestLibraryFolders(t *testing.T) {
+	root, library := tempDir(t), tempDir(t)
+	mkdirAll(t, filepath.Join(root, "steamapps"), filepath.Join(library, "steamapps"))
+	missing := filepath.Join(library, "missing")
+
+	tests := map[string]struct {
+		vdf  string
+		want []string
+	}{
+		"no libraryfolders.vdf": {"", []string{root}},
+		"current format": {`"libraryfolders"
+{
+	"0"
+	{
+		"path"		"` + filepath.ToSlash(root) + `"
+		"apps"
+		{
+			"4000"		"1024"
+		}
+	}
+	"1"
+	{
+		"path"		"` + filepath.ToSlash(library) + `"
+	}
+	"2"
+	{
+		"path"		"` + filepath.ToSlash(missing) + `"
+	}
+}`, []string{root, library}},
+		"old format": {`"LibraryFolders"
+{
+	"TimeNextStatsReport"		"1700000000"
+	"ContentStatsID"		"-123"
+	"1"		"` + filepath.ToSlash(library) + `"
+}`, []string{root, library}},
+	}
+
+	vdfPath := filepath.Join(root, "steamapps", "libraryfolders.vdf")
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			os.Remove(vdfPath)
+			if tt.vdf != "" {
+				writeFile(t, vdfPath, tt.vdf)
+			}
+			got, err := LibraryFolders(root)
+			if err != nil {
+				t.Fatalf("LibraryFolders: %v", err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("LibraryFolders() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFindGMod(t *testing.T) {
+	root := fakeLinuxHome(t)
+	library := tempDir(t)
+	writeFile(t, filepath.Join(root, "steamapps", "libraryfolders.vdf"), `"libraryfolders"
+{
+	"1"
+	{
+		"path"		"`+library+`"
+	}
+}`)
+	writeFile(t, filepath.Join(library, "steamapps", "appmanifest_4000.acf"), `"AppState"
+{
+	"appid"		"4000"
+	"installdir"		"GarrysMod"
+}`)
+	gmodDir := filepath.Join(library, "steamapps", "common", "GarrysMod")
+	mkdirAll(t, gmodDir)
+
+	install, err := FindGMod()
+	if err != nil {
+		t.Fatalf("FindGMod: %v", err)
+	}
+	want := GModInstall{SteamRoot: root, Library: library, Dir: gmodDir}
+	if *install != want {
+		t.Errorf("FindGMod() = %+v, want %+v", *install, want)
+	}
+}
@@ -0,0 +1,219 @@
+package steam
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Node is a key of a Valve KeyValues (VDF) document. A node has either a
+// string Value or Children.
+type Node struct {
+	Key      string
+	Value    string
+	Children []*Node
+}
+
+// Child returns the node at the given path of keys, matched case
+// insensitively like Steam does, or nil if there is none
+func (n *Node) Child(keys ...string) *Node {
+	node := n
+	for _, key := range keys {
+		var found *Node
+		for _, child := range node.Children {
+			if strings.EqualFold(child.Key, key) {
+				found = child
+				break
+			}
+		}
+		if found == nil {
+			return nil
+		}
+		node = found
+	}
+	return node
+}
+
+// Get returns the value at the given path of keys, or "" if there is none
+func (n *Node) Get(keys ...string) string {
+	if node := n.Child(keys...); node != nil {
+		return node.Value
+	}
+	return ""
+}
+
+// ParseVDFFile parses the VDF file at path
+func ParseVDFFile(path string) (*Node, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	root, err := ParseVDF(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return root, nil
+}
+
+// ParseVDF parses a text VDF document. The returned root node has no key
+// and holds the top-level keys as children.
+func ParseVDF(r io.Reader) (*Node, error) {
+	p := &vdfParser{r: bufio.NewReader(r), line: 1}
+	root := &Node{}
+	if err := p.parseChildren(root, false); err != nil {
+		return nil, fmt.Errorf("line %d: %w", p.line, err)
+	}
+	return root, nil
+}
+
+type vdfParser struct {
+	r    *bufio.Reader
+	line int
+}
+
+// token kinds returned by next
+const (
+	tokenEOF = iota
+	tokenString
+	tokenOpen
+	tokenClose
+)
+
+func (p *vdfParser) parseChildren(parent *Node, nested bool) error {
+	for {
+		kind, key, err := p.next()
+		if err != nil {
+			return err
+		}
+		switch kind {
+		case tokenEOF:
+			if nested {
+				return fmt.Errorf("unexpected end of file, missing }")
+			}
+			return nil
+		case tokenClose:
+			if !nested {
+				return fmt.Errorf("unexpected }")
+			}
+			return nil
+		case tokenOpen:
+			return fmt.Errorf("unexpected {")
+		}
+
+		kind, value, err := p.next()
+		if err != nil {
+			return err
+		}
+		node := &Node{Key: key}
+		switch kind {
+		case tokenString:
+			node.Value = value
+		case tokenOpen:
+			if err := p.parseChildren(node, true); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("missing value for key %q", key)
+		}
+		parent.Children = append(parent.Children, node)
+	}
+}
+
+// next returns the next token, skipping whitespace, // comments and
+// conditionals such as [$WIN32]
+func (p *vdfParser) next() (int, string, error) {
+	for {
+		c, err := p.r.ReadByte()
+		if err == io.EOF {
+			return tokenEOF, "", nil
+		} else if err != nil {
+			return 0, "", err
+		}
+
+		switch {
+		case c == '\n':
+			p.line++
+		case c == ' ' || c == '\t' || c == '\r':
+		case c == '{':
+			return tokenOpen, "", nil
+		case c == '}':
+			return tokenClose, "", nil
+		case c == '/' && p.peek() == '/':
+			if _, err := p.r.ReadString('\n'); err != nil && err != io.EOF {
+				return 0, "", err
+			}
+			p.line++
+		case c == '[':
+			if _, err := p.r.ReadString(']'); err != nil {
+				return 0, "", fmt.Errorf("unterminated conditional")
+			}
+		case c == '"':
+			s, err := p.quoted()
+			return tokenString, s, err
+		default:
+			p.r.UnreadByte()
+			return tokenString, p.bare(), nil
+		}
+	}
+}
+
+func (p *vdfParser) peek() byte {
+	b, err := p.r.Peek(1)
+	if err != nil {
+		return 0
+	}
+	return b[0]
+}
+
+// quoted reads a quoted string after its opening quote
+func (p *vdfParser) quoted() (string, error) {
+	var sb strings.Builder
+	for {
+		c, err := p.r.ReadByte()
+		if err != nil {
+			return "", fmt.Errorf("unterminated string")
+		}
+		switch c {
+		case '"':
+			return sb.String(), nil
+		case '\n':
+			p.line++
+			sb.WriteByte(c)
+		case '\\':
+			escaped, err := p.r.ReadByte()
+			if err != nil {
+				return "", fmt.Errorf("unterminated string")
+			}
+			switch escaped {
+			case 'n':
+				sb.WriteByte('\n')
+			case 't':
+				sb.WriteByte('\t')
+			default:
+				sb.WriteByte(escaped)
+			}
+		default:
+			sb.WriteByte(c)
+		}
+	}
+}
+
+// bare reads an unquoted string up to the next whitespace or brace
+func (p *vdfParser) bare() string {
+	var sb strings.Builder
+	for {
+		c, err := p.r.ReadByte()
+		if err != nil {
+			return sb.String()
+		}
+		if strings.IndexByte(" \t\r\n{}\"", c) >= 0 {
+			p.r.UnreadByte()
+			return sb.String()
+		}
+		sb.WriteByte(c)
+	}
+}
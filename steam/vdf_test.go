@@ -0,0 +1,77 @@
+package steam
+
+import (
+	"strings"
+	"testing"
+)
+
+const testAppManifest = `"AppState"
+{
+	"appid"		"4000"
+	// comments run to the end of the line
+	"installdir"	"GarrysMod"
+	"name"		"Garry's \"Mod\"\tTab"
+	"UserConfig"
+	{
+		language	english
+		"platform"	"linux" [$LINUX]
+	}
+	"empty"
+	{
+	}
+}
+`
+
+func TestParseVDF(t *testing.T) {
+	root, err := ParseVDF(strings.NewReader(testAppManifest))
+	if err != nil {
+		t.Fatalf("ParseVDF: %v", err)
+	}
+
+	tests := []struct {
+		keys []string
+		want string
+	}{
+		{[]string{"AppState", "appid"}, "4000"},
+		{[]string{"appstate", "INSTALLDIR"}, "GarrysMod"},
+		{[]string{"AppState", "name"}, "Garry's \"Mod\"\tTab"},
+		{[]string{"AppState", "UserConfig", "language"}, "english"},
+		{[]string{"AppState", "UserConfig", "platform"}, "linux"},
+		{[]string{"AppState", "missing"}, ""},
+		{[]string{"AppState", "appid", "deeper"}, ""},
+	}
+	for _, tt := range tests {
+		if got := root.Get(tt.keys...); got != tt.want {
+			t.Errorf("Get(%q) = %q, want %q", tt.keys, got, tt.want)
+		}
+	}
+
+	empty := root.Child("AppState", "empty")
+	if empty == nil || len(empty.Children) != 0 || empty.Value != "" {
+		t.Errorf("empty section = %+v, want a node without children", empty)
+	}
+	if n := len(root.Child("AppState").Children); n != 5 {
+		t.Errorf("AppState has %d children, want 5", n)
+	}
+}
+
+func TestParseVDFErrors(t *testing.T) {
+	tests := []struct {
+		doc  string
+		want string
+	}{
+		{`"a" { "b" "c"`, "line 1: unexpected end of file, missing }"},
+		{"\"a\" \"b\"\n}", "line 2: unexpected }"},
+		{`{ "a" "b" }`, "line 1: unexpected {"},
+		{"\"a\" {\n\t\"b\"\n}", `line 3: missing value for key "b"`},
+		{`"a"`, `line 1: missing value for key "a"`},
+		{"\"a\" \"unterminated\n", "line 2: unterminated string"},
+		{`"a" "b" [$WIN32`, "line 1: unterminated conditional"},
+	}
+	for _, tt := range tests {
+		_, err := ParseVDF(strings.NewReader(tt.doc))
+		if err == nil || err.Error() != tt.want {
+			t.Errorf("ParseVDF(%q) error = %v, want %q", tt.doc, err, tt.want)
+		}
+	}
+}
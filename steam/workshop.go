@@ -0,0 +1,81 @@
+package steam
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strconv"
+)
+
+// GModAppID is Garry's Mod's Steam app ID
+const GModAppID = "4000"
+
+// WorkshopItem is a workshop item the Steam client has downloaded for an app
+type WorkshopItem struct {
+	ID          string
+	Size        int64
+	TimeUpdated int64 // revision of the downloaded copy
+	Subscribed  bool  // false for items Steam is about to remove
+}
+
+// AppWorkshopPath is the manifest of the workshop items of Garry's Mod in a
+// steamapps directory
+func AppWorkshopPath(steamappsDir string) string {
+	return filepath.Join(steamappsDir, "workshop", "appworkshop_"+GModAppID+".acf")
+}
+
+// WorkshopContentDir is where the Steam client keeps the workshop items of
+// Garry's Mod, one folder per item
+func WorkshopContentDir(steamappsDir string) string {
+	return filepath.Join(steamappsDir, "workshop", "content", GModAppID)
+}
+
+// ReadAppWorkshop lists the workshop items in appworkshop_4000.acf, sorted
+// by ID
+func ReadAppWorkshop(steamappsDir string) ([]WorkshopItem, error) {
+	root, err := ParseVDFFile(AppWorkshopPath(steamappsDir))
+	if err != nil {
+		return nil, err
+	}
+	appWorkshop := root.Child("AppWorkshop")
+	if appWorkshop == nil {
+		return nil, fmt.Errorf("%s has no AppWorkshop section", AppWorkshopPath(steamappsDir))
+	}
+
+	// Items without a subscribedby entry, as in older manifests, count as
+	// subscribed
+	items := make(map[string]*WorkshopItem)
+	item := func(id string) *WorkshopItem {
+		if items[id] == nil {
+			items[id] = &WorkshopItem{ID: id, Subscribed: true}
+		}
+		return items[id]
+	}
+
+	if installed := appWorkshop.Child("WorkshopItemsInstalled"); installed != nil {
+		for _, node := range installed.Children {
+			i := item(node.Key)
+			i.Size, _ = strconv.ParseInt(node.Get("size"), 10, 64)
+			i.TimeUpdated, _ = strconv.ParseInt(node.Get("timeupdated"), 10, 64)
+		}
+	}
+
+	if details := appWorkshop.Child("WorkshopItemDetails"); details != nil {
+		for _, node := range details.Children {
+			i := item(node.Key)
+			if i.TimeUpdated == 0 {
+				i.TimeUpdated, _ = strconv.ParseInt(node.Get("timeupdated"), 10, 64)
+			}
+			if subscriber := node.Child("subscribedby"); subscriber != nil {
+				i.Subscribed = subscriber.Value != "" && subscriber.Value != "0"
+			}
+		}
+	}
+
+	list := make([]WorkshopItem, 0, len(items))
+	for _, i := range items {
+		list = append(list, *i)
+	}
+	sort.Slice(list, func(a, b int) bool { return list[a].ID < list[b].ID })
+	return list, nil
+}
@@ -0,0 +1,89 @@
+package steam
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func writeAppWorkshop(t *testing.T, content string) string {
+	t.Helper()
+	steamappsDir := t.TempDir()
+	path := AppWorkshopPath(steamappsDir)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return steamappsDir
+}
+
+func TestReadAppWorkshop(t *testing.T) {
+	steamappsDir := writeAppWorkshop(t, `"AppWorkshop"
+{
+	"appid"		"4000"
+	"WorkshopItemsInstalled"
+	{
+		"222"
+		{
+			"size"		"2048"
+			"timeupdated"		"1700000000"
+		}
+		"111"
+		{
+			"size"		"1024"
+			"timeupdated"		"1600000000"
+		}
+		"333"
+		{
+			"size"		"512"
+			"timeupdated"		"1500000000"
+		}
+	}
+	"WorkshopItemDetails"
+	{
+		"111"
+		{
+			"timeupdated"		"1650000000"
+			"subscribedby"		"12345"
+		}
+		"222"
+		{
+			"subscribedby"		"0"
+		}
+		"444"
+		{
+			"timeupdated"		"1400000000"
+		}
+	}
+}
+`)
+
+	items, err := ReadAppWorkshop(steamappsDir)
+	if err != nil {
+		t.Fatalf("ReadAppWorkshop: %v", err)
+	}
+
+	// Whether an item is subscribed is decided per item, those that don't
+	// say count as subscribed
+	want := []WorkshopItem{
+		{ID: "111", Size: 1024, TimeUpdated: 1600000000, Subscribed: true},
+		{ID: "222", Size: 2048, TimeUpdated: 1700000000, Subscribed: false},
+		{ID: "333", Size: 512, TimeUpdated: 1500000000, Subscribed: true},
+		{ID: "444", TimeUpdated: 1400000000, Subscribed: true},
+	}
+	if !slices.Equal(items, want) {
+		t.Errorf("items = %+v\nwant %+v", items, want)
+	}
+}
+
+func TestReadAppWorkshopErrors(t *testing.T) {
+	if _, err := ReadAppWorkshop(t.TempDir()); err == nil {
+		t.Error("reading a missing manifest succeeded")
+	}
+	if _, err := ReadAppWorkshop(writeAppWorkshop(t, `"AppState" { }`)); err == nil {
+		t.Error("reading a manifest without AppWorkshop succeeded")
+	}
+}
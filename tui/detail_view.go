@@ -83,6 +83,9 @@ func (m *DetailModel) View() string {
 	if a.Enabled {
 		status = "✅ Enabled"
 	}
+	if a.UpdateAvailable {
+		status += " (update available)"
+	}
 
 	return fmt.Sprintf(
 		"Addon Details\n\n"+
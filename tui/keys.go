@@ -18,16 +18,18 @@ type KeyMapEntry struct {
 
 // KeyMap is the master registry of all keybindings and their actions
 type KeyMap struct {
-	Refresh KeyMapEntry
-	Quit    KeyMapEntry
-	Input   KeyMapEntry
-	Detail  KeyMapEntry
-	Enable  KeyMapEntry
-	Disable KeyMapEntry
-	Reload  KeyMapEntry
-	Install KeyMapEntry
-	Remove  KeyMapEntry
-	Cancel  KeyMapEntry
+	Refresh  KeyMapEntry
+	Quit     KeyMapEntry
+	Input    KeyMapEntry
+	Detail   KeyMapEntry
+	Enable   KeyMapEntry
+	Disable  KeyMapEntry
+	Reload   KeyMapEntry
+	Install  KeyMapEntry
+	Remove   KeyMapEntry
+	Cancel   KeyMapEntry
+	Settings KeyMapEntry
+	Targets  KeyMapEntry
 }
 
 // GlobalKeyMap is the single master keymap with all keybindings and actions
@@ -122,6 +124,24 @@ var GlobalKeyMap = KeyMap{
 			return cancelMsg{}
 		},
 	},
+	Settings: KeyMapEntry{
+		Binding: key.NewBinding(
+			key.WithKeys("o"),
+			key.WithHelp("o", "settings"),
+		),
+		Action: func(ctx *KeyContext) tea.Msg {
+			return requestSettingsViewMsg{}
+		},
+	},
+	Targets: KeyMapEntry{
+		Binding: key.NewBinding(
+			key.WithKeys("t"),
+			key.WithHelp("t", "switch target"),
+		),
+		Action: func(ctx *KeyContext) tea.Msg {
+			return requestTargetViewMsg{}
+		},
+	},
 }
 
 // Update processes a key message against a subset of keys and executes the corresponding action
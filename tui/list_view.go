@@ -25,6 +25,9 @@ func (i addonItem) Description() string {
 	if i.addon.Enabled {
 		status = "✅ Enabled"
 	}
+	if i.addon.UpdateAvailable {
+		status += " • ⬆️ Update available"
+	}
 	return status
 }
 
@@ -105,6 +108,8 @@ func NewListModel(manager *addon.Manager) *ListModel {
 	// Define the subset of keys allowed in list view
 	keyMaps := []KeyMapEntry{
 		GlobalKeyMap.Input,
+		GlobalKeyMap.Settings,
+		GlobalKeyMap.Targets,
 		GlobalKeyMap.Refresh,
 		GlobalKeyMap.Quit,
 	}
@@ -126,6 +131,8 @@ func NewListModel(manager *addon.Manager) *ListModel {
 	addonList.AdditionalFullHelpKeys = func() []key.Binding {
 		return []key.Binding{
 			GlobalKeyMap.Input.Binding,
+			GlobalKeyMap.Settings.Binding,
+			GlobalKeyMap.Targets.Binding,
 			GlobalKeyMap.Refresh.Binding,
 			GlobalKeyMap.Quit.Binding,
 		}
@@ -4,32 +4,106 @@ import (
 	"fmt"
 
 	"gmod-addon-manager/addon"
+	"gmod-addon-manager/config"
 
+	"github.com/charmbracelet/bubbles/spinner"
 	tea "github.com/charmbracelet/bubbletea"
 )
 
 // Model is the root TUI model that orchestrates all views
 type Model struct {
-	manager     *addon.Manager
-	state       string // "list", "input", "detail"
-	error       error
-	loading     bool
-	listModel   *ListModel
-	inputModel  *InputModel
-	detailModel *DetailModel
+	manager       *addon.Manager
+	state         string // "list", "input", "detail", "settings", "targets"
+	error         error
+	loading       bool // an install is running
+	listModel     *ListModel
+	inputModel    *InputModel
+	detailModel   *DetailModel
+	progressModel *ProgressModel
+	settingsModel *SettingsModel
+	targetModel   *TargetModel
+	targets       *Targets
+	size          tea.WindowSizeMsg // last size, for views created when switching targets
 }
 
-func NewModel(manager *addon.Manager) Model {
-	return Model{
-		manager:     manager,
+// NewModel creates the root model. targets may be nil when there is only
+// the one install.
+func NewModel(manager *addon.Manager, targets *Targets) Model {
+	if targets == nil {
+		targets = &Targets{Names: []string{config.DefaultTargetName}, Current: config.DefaultTargetName}
+	}
+
+	m := Model{
 		state:       "list",
 		loading:     false,
-		listModel:   NewListModel(manager),
-		inputModel:  NewInputModel(manager),
-		detailModel: NewDetailModel(manager),
+		targets:     targets,
+		targetModel: NewTargetModel(targets),
+	}
+	m.useManager(manager)
+	return m
+}
+
+// useManager points every view at a manager, replacing the views of the
+// previous target
+func (m *Model) useManager(manager *addon.Manager) {
+	m.manager = manager
+	m.listModel = NewListModel(manager)
+	m.inputModel = NewInputModel(manager)
+	m.detailModel = NewDetailModel(manager)
+	m.progressModel = NewProgressModel(manager)
+	m.settingsModel = NewSettingsModel(m.targets.Current)
+
+	if len(m.targets.Names) > 1 {
+		m.listModel.list.Title = fmt.Sprintf("Garry's Mod Addons (%s)", m.targets.Current)
+	}
+	if m.size.Width > 0 {
+		m.resize(m.size)
 	}
 }
 
+// resize passes the window size to every view, not only the active one, so
+// views opened later and the progress shown below them fit the window
+func (m *Model) resize(size tea.WindowSizeMsg) tea.Cmd {
+	m.size = size
+	var cmds []tea.Cmd
+	for _, view := range []tea.Model{m.listModel, m.inputModel, m.detailModel, m.progressModel, m.settingsModel, m.targetModel} {
+		_, cmd := view.Update(size)
+		cmds = append(cmds, cmd)
+	}
+	return tea.Batch(cmds...)
+}
+
+// NewProgram creates the TUI program and routes the install progress of the
+// manager, and of the managers opened when switching targets, to it as
+// messages
+func NewProgram(manager *addon.Manager, targets *Targets, opts ...tea.ProgramOption) *tea.Program {
+	var p *tea.Program
+	routeProgress := func(manager *addon.Manager) {
+		manager.SetProgressHandler(func(event addon.ProgressEvent) {
+			p.Send(progressMsg{event})
+		})
+	}
+
+	if targets != nil && targets.Open != nil {
+		open := targets.Open
+		targets = &Targets{
+			Names:   targets.Names,
+			Current: targets.Current,
+			Open: func(name string) (*addon.Manager, error) {
+				manager, err := open(name)
+				if err == nil {
+					routeProgress(manager)
+				}
+				return manager, err
+			},
+		}
+	}
+
+	p = tea.NewProgram(NewModel(manager, targets), opts...)
+	routeProgress(manager)
+	return p
+}
+
 func (m Model) Init() tea.Cmd {
 	return nil
 }
@@ -46,17 +120,21 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	}
 
 	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		return m, m.resize(msg)
+
 	case errorMsg:
 		m.error = msg.err
-		m.loading = false
 
 	case successMsg:
 		m.error = nil
-		m.loading = false
+
+	case progressMsg, spinner.TickMsg:
+		_, cmd = m.progressModel.Update(msg)
+		return m, cmd
 
 	case cancelMsg:
 		m.error = nil
-		m.loading = false
 		switch m.state {
 		case "list":
 			return m, tea.Quit
@@ -64,6 +142,8 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, func() tea.Msg { return requestListViewMsg{} }
 		case "detail":
 			return m, func() tea.Msg { return requestListViewMsg{} }
+		case "settings", "targets":
+			return m, func() tea.Msg { return requestListViewMsg{} }
 		}
 
 	case enableAddonMsg:
@@ -94,13 +174,21 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 
 	case installAddonMsg:
-		return m, func() tea.Msg {
-			err := m.manager.GetAddon(msg.addonID)
-			if err != nil {
-				return errorMsg{err}
-			}
-			return successMsg{fmt.Sprintf("Addon %s installed successfully", msg.addonID)}
+		if msg.addonID == "" || m.progressModel.Queued(msg.addonID) {
+			return m, nil
 		}
+		m.inputModel.Update(msg)
+		m.loading = true
+		return m, m.progressModel.Start(msg.addonID)
+
+	case installDoneMsg:
+		if msg.err != nil {
+			m.error = fmt.Errorf("failed to install addon %s: %w", msg.addonID, msg.err)
+		}
+		m.listModel.Update(successMsg{})
+		m.progressModel.Finish(msg.addonID)
+		m.loading = m.progressModel.Busy()
+		return m, nil
 
 	case removeAddonMsg:
 		return m, func() tea.Msg {
@@ -125,6 +213,45 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.state = "detail"
 		m.detailModel.Update(msg)
 		return m, nil
+
+	case requestSettingsViewMsg:
+		m.state = "settings"
+		m.settingsModel.Update(msg)
+		return m, nil
+
+	case requestTargetViewMsg:
+		m.state = "targets"
+		m.targetModel.Update(msg)
+		return m, nil
+
+	case switchTargetMsg:
+		if msg.target == m.targets.Current {
+			return m, func() tea.Msg { return requestListViewMsg{} }
+		}
+		// The running installs belong to the current manager
+		if m.progressModel.Busy() {
+			m.error = fmt.Errorf("wait for the running installs to finish before switching targets")
+			return m, nil
+		}
+		if m.targets.Open == nil {
+			m.error = fmt.Errorf("switching targets is not supported")
+			return m, nil
+		}
+		open := m.targets.Open
+		return m, func() tea.Msg {
+			manager, err := open(msg.target)
+			if err != nil {
+				return errorMsg{fmt.Errorf("failed to open target %s: %w", msg.target, err)}
+			}
+			return targetOpenedMsg{target: msg.target, manager: manager}
+		}
+
+	case targetOpenedMsg:
+		m.targets.Current = msg.target
+		m.useManager(msg.manager)
+		m.state = "list"
+		m.loading = false
+		return m, nil
 	}
 
 	// Delegate to the active component
@@ -135,6 +262,10 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		_, cmd = m.inputModel.Update(msg)
 	case "detail":
 		_, cmd = m.detailModel.Update(msg)
+	case "settings":
+		_, cmd = m.settingsModel.Update(msg)
+	case "targets":
+		_, cmd = m.targetModel.Update(msg)
 	}
 
 	return m, cmd
@@ -145,18 +276,24 @@ func (m Model) View() string {
 		return fmt.Sprintf("Error: %v\nPress any key to continue...", m.error)
 	}
 
-	if m.loading {
-		return "Loading... Please wait.\n"
-	}
-
+	var view string
 	switch m.state {
 	case "list":
-		return m.listModel.View()
+		view = m.listModel.View()
 	case "input":
-		return m.inputModel.View()
+		view = m.inputModel.View()
 	case "detail":
-		return m.detailModel.View()
+		view = m.detailModel.View()
+	case "settings":
+		view = m.settingsModel.View()
+	case "targets":
+		view = m.targetModel.View()
 	default:
 		return "Unknown state"
 	}
+
+	if m.loading {
+		view += "\n\n" + m.progressModel.View()
+	}
+	return view
 }
@@ -0,0 +1,162 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"gmod-addon-manager/addon"
+	"gmod-addon-manager/file"
+
+	"github.com/charmbracelet/bubbles/progress"
+	"github.com/charmbracelet/bubbles/spinner"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+var (
+	stageDoneStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("42"))
+	stageActiveStyle  = lipgloss.NewStyle().Bold(true)
+	stagePendingStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+)
+
+// ProgressModel shows the jobs running on the manager's queue and the ones
+// waiting behind them
+type ProgressModel struct {
+	spinner  spinner.Model
+	progress progress.Model
+	jobs     *addon.JobQueue
+	messages map[string]string // latest progress message per addon
+	status   string            // latest message not about one addon, like a steam login
+}
+
+func NewProgressModel(manager *addon.Manager) *ProgressModel {
+	return &ProgressModel{
+		spinner:  spinner.New(spinner.WithSpinner(spinner.Dot)),
+		progress: progress.New(progress.WithDefaultGradient()),
+		jobs:     manager.Jobs(),
+		messages: make(map[string]string),
+	}
+}
+
+// Busy reports whether any install is waiting or running
+func (m *ProgressModel) Busy() bool {
+	return m.jobs.Active()
+}
+
+// Queued reports whether an addon is running or waiting to be installed
+func (m *ProgressModel) Queued(addonID string) bool {
+	return slices.ContainsFunc(m.jobs.Jobs(), func(job addon.Job) bool {
+		return job.ID == addonID && job.State.Active()
+	})
+}
+
+// Start queues an install and returns the command waiting for it to finish
+func (m *ProgressModel) Start(addonID string) tea.Cmd {
+	handle := m.jobs.Add(context.Background(), addonID, addon.JobInstall)
+	wait := func() tea.Msg {
+		job := handle.Wait()
+		return installDoneMsg{addonID: job.ID, err: job.Err}
+	}
+	return tea.Batch(m.spinner.Tick, wait)
+}
+
+// Finish forgets a finished install once its outcome has been reported
+func (m *ProgressModel) Finish(addonID string) {
+	delete(m.messages, addonID)
+	m.jobs.Forget(addonID)
+	if !m.Busy() {
+		m.status = ""
+		m.jobs.ClearFinished()
+	}
+}
+
+func (m *ProgressModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m *ProgressModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case progressMsg:
+		event := msg.event
+		if event.Message == "" {
+			return m, nil
+		}
+		if event.ID == "" {
+			m.status = event.Message
+		} else {
+			m.messages[event.ID] = event.Message
+		}
+
+	case tea.WindowSizeMsg:
+		m.progress.Width = min(msg.Width-4, 60)
+
+	case spinner.TickMsg:
+		if !m.Busy() {
+			return m, nil
+		}
+		var cmd tea.Cmd
+		m.spinner, cmd = m.spinner.Update(msg)
+		return m, cmd
+	}
+
+	return m, nil
+}
+
+func (m *ProgressModel) View() string {
+	var lines, queued []string
+	for _, job := range m.jobs.Jobs() {
+		switch job.State {
+		case addon.JobRunning:
+			lines = append(lines, m.jobView(job))
+		case addon.JobQueued:
+			queued = append(queued, job.ID)
+		}
+	}
+
+	if m.status != "" {
+		lines = append(lines, m.status)
+	}
+	if len(queued) > 0 {
+		lines = append(lines, fmt.Sprintf("Queued: %s", strings.Join(queued, ", ")))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// jobView renders the stages and byte progress of a running install
+func (m *ProgressModel) jobView(job addon.Job) string {
+	lines := []string{
+		fmt.Sprintf("%s Installing addon %s", m.spinner.View(), job.ID),
+		stagesView(job.Stage),
+	}
+
+	if job.Total > 0 {
+		lines = append(lines, fmt.Sprintf("%s %s / %s",
+			m.progress.ViewAs(float64(job.Done)/float64(job.Total)),
+			file.FormatSize(job.Done), file.FormatSize(job.Total)))
+	} else if job.Done > 0 {
+		lines = append(lines, file.FormatSize(job.Done))
+	}
+	if message := m.messages[job.ID]; message != "" {
+		lines = append(lines, message)
+	}
+
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// stagesView renders every stage, marking the ones already finished
+func stagesView(current addon.InstallStage) string {
+	var stages []string
+	for _, stage := range addon.InstallStages {
+		switch {
+		case stage < current:
+			stages = append(stages, stageDoneStyle.Render("✓ "+stage.String()))
+		case stage == current:
+			stages = append(stages, stageActiveStyle.Render("• "+stage.String()))
+		default:
+			stages = append(stages, stagePendingStyle.Render("○ "+stage.String()))
+		}
+	}
+	return strings.Join(stages, "  ")
+}
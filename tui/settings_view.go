@@ -0,0 +1,261 @@
+package tui
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"strings"
+
+	"gmod-addon-manager/config"
+
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+var (
+	settingCursorStyle  = lipgloss.NewStyle().Bold(true)
+	settingDefaultStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+	problemErrorStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("196"))
+	problemWarningStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("214"))
+)
+
+// Keys of the settings view, which handles its own navigation
+var settingsKeys = struct {
+	Up, Down, Edit, Save, Suggest key.Binding
+}{
+	Up:      key.NewBinding(key.WithKeys("up", "k"), key.WithHelp("↑/k", "up")),
+	Down:    key.NewBinding(key.WithKeys("down", "j"), key.WithHelp("↓/j", "down")),
+	Edit:    key.NewBinding(key.WithKeys("enter"), key.WithHelp("enter", "edit")),
+	Save:    key.NewBinding(key.WithKeys("enter"), key.WithHelp("enter", "save")),
+	Suggest: key.NewBinding(key.WithKeys("tab"), key.WithHelp("tab", "use suggestion")),
+}
+
+// SettingsModel lists the config settings and edits them one at a time.
+// Changes are validated and saved to the config file, and take effect the
+// next time the manager starts.
+type SettingsModel struct {
+	target    string
+	raw       *config.Config // the config file as written
+	selected  *config.Config // the target's settings
+	effective *config.Config // the target's settings with defaults filled in
+	cursor    int
+	editing   bool
+	input     textinput.Model
+	suggested string // detected value of the setting being edited
+	problems  []config.Problem
+	status    string
+	err       error
+	keyMaps   []KeyMapEntry
+	help      help.Model
+}
+
+// NewSettingsModel creates the settings view for a target. Install paths
+// are changed for that target only, other settings for every target.
+func NewSettingsModel(target string) *SettingsModel {
+	return &SettingsModel{
+		target:  target,
+		input:   textinput.New(),
+		keyMaps: []KeyMapEntry{GlobalKeyMap.Cancel},
+		help:    help.New(),
+	}
+}
+
+// load rereads the config file so the view shows what is saved
+func (m *SettingsModel) load() {
+	raw, err := config.LoadConfigFile()
+	if err == nil {
+		err = m.use(raw)
+	}
+	m.err = err
+}
+
+func (m *SettingsModel) use(raw *config.Config) error {
+	selected, err := raw.ForTarget(m.target)
+	if err != nil {
+		return err
+	}
+	m.raw, m.selected, m.effective = raw, selected, selected.WithDefaults()
+	return nil
+}
+
+func (m *SettingsModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m *SettingsModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case requestSettingsViewMsg:
+		m.editing, m.problems, m.status = false, nil, ""
+		m.load()
+		return m, nil
+
+	case tea.WindowSizeMsg:
+		m.input.Width = msg.Width
+		m.help.Width = msg.Width
+		return m, nil
+
+	case tea.KeyMsg:
+		if m.editing {
+			return m.updateEditing(msg)
+		}
+		if m.raw == nil {
+			break
+		}
+		switch {
+		case key.Matches(msg, settingsKeys.Up):
+			m.cursor = max(m.cursor-1, 0)
+		case key.Matches(msg, settingsKeys.Down):
+			m.cursor = min(m.cursor+1, len(config.Settings)-1)
+		case key.Matches(msg, settingsKeys.Edit):
+			return m, m.startEditing()
+		}
+	}
+
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		result := GlobalKeyMap.Update(msg, m.keyMaps, &KeyContext{})
+		if result != nil {
+			return m, func() tea.Msg { return result }
+		}
+	}
+	return m, nil
+}
+
+func (m *SettingsModel) startEditing() tea.Cmd {
+	setting := config.Settings[m.cursor]
+
+	// What the setting would be if it were left empty
+	unset := *m.selected
+	setting.Set(&unset, "")
+	m.suggested = setting.Get(unset.WithDefaults())
+
+	m.editing, m.problems, m.status = true, nil, ""
+	m.input.Reset()
+	m.input.SetValue(setting.Get(m.selected))
+	m.input.Placeholder = m.suggested
+	m.input.EchoMode = textinput.EchoNormal
+	if setting.Secret {
+		m.input.EchoMode = textinput.EchoPassword
+	}
+	return m.input.Focus()
+}
+
+func (m *SettingsModel) updateEditing(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch {
+	case key.Matches(msg, GlobalKeyMap.Cancel.Binding):
+		m.editing = false
+		m.input.Blur()
+		return m, nil
+
+	case key.Matches(msg, settingsKeys.Suggest):
+		m.input.SetValue(m.suggested)
+		m.input.CursorEnd()
+		return m, nil
+
+	case key.Matches(msg, settingsKeys.Save):
+		m.save(config.Settings[m.cursor], m.input.Value())
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(msg)
+	return m, cmd
+}
+
+// save validates the changed config and writes it, keeping the editor open
+// when the change would introduce errors
+func (m *SettingsModel) save(setting config.Setting, value string) {
+	changed := *m.raw
+	changed.Targets = maps.Clone(m.raw.Targets)
+	err := changed.EditTarget(m.target, func(selected *config.Config) error {
+		return setting.Set(selected, value)
+	})
+	if err != nil {
+		m.problems = []config.Problem{{Key: setting.Key, Severity: config.SeverityError, Message: err.Error()}}
+		return
+	}
+
+	problems, err := config.ValidateAndSave(&changed)
+	m.problems = problems
+	var invalid *config.ValidationError
+	if errors.As(err, &invalid) {
+		m.status = "Not saved, the change introduces errors"
+		return
+	}
+	if err != nil {
+		m.status = fmt.Sprintf("Not saved: %v", err)
+		return
+	}
+
+	m.use(&changed)
+	m.editing = false
+	m.input.Blur()
+	m.status = fmt.Sprintf("Saved %s, restart to apply", setting.Key)
+}
+
+func (m *SettingsModel) View() string {
+	if m.err != nil {
+		return fmt.Sprintf("Settings\n\nFailed to load config: %v\n\nPress [Esc] to return", m.err)
+	}
+	if m.raw == nil {
+		return "Settings\n\nLoading..."
+	}
+
+	var b strings.Builder
+	if m.target != "" && m.target != config.DefaultTargetName {
+		fmt.Fprintf(&b, "Settings (%s)\n\n", m.target)
+	} else {
+		b.WriteString("Settings\n\n")
+	}
+	for i, setting := range config.Settings {
+		cursor := "  "
+		label := fmt.Sprintf("%-20s", setting.Label)
+		if i == m.cursor {
+			cursor = "> "
+			label = settingCursorStyle.Render(label)
+		}
+
+		value := setting.Display(m.effective, false)
+		if setting.Get(m.selected) == "" && value != "" {
+			value += settingDefaultStyle.Render(" (default)")
+		}
+		fmt.Fprintf(&b, "%s%s %s\n", cursor, label, value)
+
+		if i == m.cursor && m.editing {
+			fmt.Fprintf(&b, "    %s\n", m.input.View())
+		}
+	}
+
+	if m.status != "" {
+		fmt.Fprintf(&b, "\n%s\n", m.status)
+	}
+	for _, p := range m.problems {
+		style := problemWarningStyle
+		if p.Severity == config.SeverityError {
+			style = problemErrorStyle
+		}
+		fmt.Fprintf(&b, "%s\n", style.Render(fmt.Sprintf("%s: %s", p.Severity, p)))
+		if p.Fix != "" {
+			fmt.Fprintf(&b, "  fix: %s\n", p.Fix)
+		}
+	}
+
+	b.WriteString("\n")
+	if m.editing {
+		b.WriteString(m.help.ShortHelpView([]key.Binding{
+			settingsKeys.Save,
+			settingsKeys.Suggest,
+			GlobalKeyMap.Cancel.Binding,
+		}))
+	} else {
+		b.WriteString(m.help.ShortHelpView([]key.Binding{
+			settingsKeys.Up,
+			settingsKeys.Down,
+			settingsKeys.Edit,
+			GlobalKeyMap.Cancel.Binding,
+		}))
+	}
+	return b.String()
+}
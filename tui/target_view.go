@@ -0,0 +1,106 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"gmod-addon-manager/addon"
+
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Targets are the GMod installs the TUI can switch between
+type Targets struct {
+	Names   []string
+	Current string
+
+	// Open creates a manager for the named target
+	Open func(name string) (*addon.Manager, error)
+}
+
+// TargetModel lists the targets and switches to the selected one
+type TargetModel struct {
+	targets *Targets
+	cursor  int
+	keyMaps []KeyMapEntry
+	help    help.Model
+}
+
+func NewTargetModel(targets *Targets) *TargetModel {
+	return &TargetModel{
+		targets: targets,
+		keyMaps: []KeyMapEntry{GlobalKeyMap.Cancel},
+		help:    help.New(),
+	}
+}
+
+func (m *TargetModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m *TargetModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case requestTargetViewMsg:
+		// Start on the target in use
+		for i, name := range m.targets.Names {
+			if name == m.targets.Current {
+				m.cursor = i
+			}
+		}
+
+	case tea.WindowSizeMsg:
+		m.help.Width = msg.Width
+
+	case tea.KeyMsg:
+		switch {
+		case key.Matches(msg, settingsKeys.Up):
+			m.cursor = max(m.cursor-1, 0)
+			return m, nil
+		case key.Matches(msg, settingsKeys.Down):
+			m.cursor = min(m.cursor+1, len(m.targets.Names)-1)
+			return m, nil
+		case key.Matches(msg, targetSelectKey):
+			name := m.targets.Names[m.cursor]
+			return m, func() tea.Msg { return switchTargetMsg{target: name} }
+		}
+
+		result := GlobalKeyMap.Update(msg, m.keyMaps, &KeyContext{})
+		if result != nil {
+			return m, func() tea.Msg { return result }
+		}
+	}
+
+	return m, nil
+}
+
+var targetSelectKey = key.NewBinding(key.WithKeys("enter"), key.WithHelp("enter", "switch"))
+
+func (m *TargetModel) View() string {
+	var b strings.Builder
+	b.WriteString("Targets\n\n")
+	for i, name := range m.targets.Names {
+		cursor := "  "
+		if i == m.cursor {
+			cursor = "> "
+		}
+		current := ""
+		if name == m.targets.Current {
+			current = " (in use)"
+		}
+		fmt.Fprintf(&b, "%s%s%s\n", cursor, name, current)
+	}
+	if len(m.targets.Names) < 2 {
+		b.WriteString("\nAdd other installs with `gmod-addon-manager target add`.\n")
+	}
+
+	b.WriteString("\n")
+	b.WriteString(m.help.ShortHelpView([]key.Binding{
+		settingsKeys.Up,
+		settingsKeys.Down,
+		targetSelectKey,
+		GlobalKeyMap.Cancel.Binding,
+	}))
+	return b.String()
+}
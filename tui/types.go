@@ -1,15 +1,28 @@
 package tui
 
+import "gmod-addon-manager/addon"
+
 // Message types for the TUI application
 
 type errorMsg struct{ err error }
 type successMsg struct{ msg string }
 type cancelMsg struct{}
 
+// progressMsg carries install progress reported by the manager
+type progressMsg struct{ event addon.ProgressEvent }
+
+// installDoneMsg is sent when a queued install finishes
+type installDoneMsg struct {
+	addonID string
+	err     error
+}
+
 // View transition messages
 type requestListViewMsg struct{}
 type requestInputViewMsg struct{}
 type requestDetailViewMsg struct{ addonID string }
+type requestSettingsViewMsg struct{}
+type requestTargetViewMsg struct{}
 
 // Action messages
 type enableAddonMsg struct{ addonID string }
@@ -17,3 +30,10 @@ type disableAddonMsg struct{ addonID string }
 type reloadAddonMsg struct{ addonID string }
 type installAddonMsg struct{ addonID string }
 type removeAddonMsg struct{ addonID string }
+type switchTargetMsg struct{ target string }
+
+// targetOpenedMsg carries the manager of the target switched to
+type targetOpenedMsg struct {
+	target  string
+	manager *addon.Manager
+}